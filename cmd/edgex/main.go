@@ -29,6 +29,7 @@ import (
 	"github.com/Circutor/edgex/internal/core/metadata"
 	"github.com/Circutor/edgex/internal/export/client"
 	"github.com/Circutor/edgex/internal/export/distro"
+	"github.com/Circutor/edgex/internal/pkg/bus"
 	"github.com/Circutor/edgex/internal/pkg/correlation/models"
 	"github.com/Circutor/edgex/internal/pkg/startup"
 	"github.com/Circutor/edgex/internal/support/logging"
@@ -45,7 +46,6 @@ func main() {
 
 	// Make chanels
 	errCh := make(chan error, 3)
-	eventCh := make(chan *models.Event, 10)
 	listenForInterrupt(errCh)
 
 	// Initialize support-logging
@@ -58,6 +58,13 @@ func main() {
 	loggingClient = logger.NewClient("edgex", true, loggingRemoteURL, logger.InfoLog)
 	loggingClient.Info(fmt.Sprintf("Starting EdgeX %s ", edgex.Version))
 
+	// Initialize export-distro before core-data, its message bus tells whether
+	// the events core-data publishes on the in-process channel are received
+	iniExportDistro()
+	if distro.Configuration.MessageQueue.Type == bus.Channel {
+		bus.SetReceiver(true)
+	}
+
 	// Initialize core-data
 	iniCoreData(errCh)
 
@@ -74,18 +81,24 @@ func main() {
 	// Initialize core-command
 	iniCoreCommand(errCh)
 
-	// Initialize export-distro
-	iniExportDistro()
-
 	// All clients and HTTP servers have been started
 	loggingClient.Info("EdgeX started in: " + time.Since(start).String())
 
-	// There can be another receivers that can be initialiced here
-	distro.MangosReceiver(eventCh)
-	distro.Loop(errCh, eventCh)
+	// Core-data hands the events to export-distro in the same process when using the in-process bus
+	if distro.Configuration.MessageQueue.Type == bus.Channel {
+		distro.Loop(errCh, bus.Events())
+	} else {
+		eventCh := make(chan *models.Event, 10)
+		if err := distro.StartReceiver(eventCh); err != nil {
+			loggingClient.Error(err.Error())
+			os.Exit(1)
+		}
+		distro.Loop(errCh, eventCh)
+	}
 
 	// Destroy all clients
 	data.Destruct()
+	distro.Destruct()
 	client.Destruct()
 	metadata.Destruct()
 	command.Destruct()
@@ -56,8 +56,11 @@ func main() {
 
 	listenForInterrupt(errs)
 
-	// There can be another receivers that can be initialiced here
-	distro.MangosReceiver(eventCh)
+	// Receive the events from the configured message bus
+	if err := distro.StartReceiver(eventCh); err != nil {
+		distro.LoggingClient.Error(err.Error())
+		os.Exit(1)
+	}
 	distro.Loop(errs, eventCh)
 
 	// Time it took to start service
@@ -0,0 +1,226 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/db"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Aggregation functions
+const (
+	aggregateAvg   = "avg"
+	aggregateMin   = "min"
+	aggregateMax   = "max"
+	aggregateSum   = "sum"
+	aggregateCount = "count"
+	aggregateLast  = "last"
+)
+
+var aggregateFuncs = []string{aggregateAvg, aggregateMin, aggregateMax, aggregateSum, aggregateCount, aggregateLast}
+
+// Readings read at once while aggregating, only the buckets are kept in memory
+const aggregatePageSize = 1000
+
+// Aggregated values of the numeric readings whose origin time is in [Start, End)
+// Only the requested functions are set
+type ReadingAggregate struct {
+	Start int64    `json:"start"`
+	End   int64    `json:"end"`
+	Avg   *float64 `json:"avg,omitempty"`
+	Min   *float64 `json:"min,omitempty"`
+	Max   *float64 `json:"max,omitempty"`
+	Sum   *float64 `json:"sum,omitempty"`
+	Count *int     `json:"count,omitempty"`
+	Last  *float64 `json:"last,omitempty"`
+}
+
+// Running values of a bucket
+type aggregateBucket struct {
+	count      int
+	sum        float64
+	min        float64
+	max        float64
+	last       float64
+	lastOrigin int64
+}
+
+// Parse the bucket duration in milliseconds
+// Accepts Go durations (e.g. 15m, 1h) and a number of days (e.g. 1d)
+func parseBucket(bucket string) (int64, error) {
+	var d time.Duration
+	if strings.HasSuffix(bucket, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(bucket, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid bucket '%s'", bucket)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		d, err = time.ParseDuration(bucket)
+		if err != nil {
+			return 0, fmt.Errorf("invalid bucket '%s'", bucket)
+		}
+	}
+
+	ms := int64(d / time.Millisecond)
+	if ms <= 0 {
+		return 0, fmt.Errorf("invalid bucket '%s', it should be at least 1ms", bucket)
+	}
+	return ms, nil
+}
+
+// Parse the comma separated list of aggregation functions
+// All of them are returned when the list is empty
+func parseAggregateFuncs(fns string) ([]string, error) {
+	if fns == "" {
+		return aggregateFuncs, nil
+	}
+
+	var parsed []string
+	for _, fn := range strings.Split(fns, ",") {
+		fn = strings.TrimSpace(fn)
+		found := false
+		for _, valid := range aggregateFuncs {
+			if fn == valid {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid aggregation function '%s', expected one of %s",
+				fn, strings.Join(aggregateFuncs, ","))
+		}
+		parsed = append(parsed, fn)
+	}
+	return parsed, nil
+}
+
+// Aggregate the numeric readings of a device with the given name in buckets of the given duration
+// Buckets are aligned to multiples of their duration since the epoch and empty ones are not returned
+func aggregateReadings(deviceId string, name string, start int64, end int64, bucket int64, fns []string) ([]ReadingAggregate, error) {
+	// Readings are indexed by creation time, which is never earlier than their origin, so those
+	// with origin in the window are created from its start on. Back-filled ones are created after
+	// its end, all the readings created since the start are scanned page by page
+	buckets := newReadingBuckets(start, end, bucket)
+	from, offset := start, 0
+	for {
+		readings, err := dbClient.ReadingsByDeviceAndName(deviceId, name, from, math.MaxInt64, offset, aggregatePageSize, db.OldestFirst)
+		if err != nil {
+			LoggingClient.Error(err.Error())
+			return nil, err
+		}
+		for _, r := range readings {
+			buckets.add(r)
+
+			// The next page starts after the readings created at the same time already read
+			if r.Created > from {
+				from, offset = r.Created, 0
+			}
+			offset++
+		}
+		if len(readings) < aggregatePageSize {
+			break
+		}
+	}
+
+	return buckets.aggregates(fns), nil
+}
+
+// Group the numeric readings with origin in [start, end) by bucket and apply the aggregation functions
+func bucketReadings(readings []contract.Reading, start int64, end int64, bucket int64, fns []string) []ReadingAggregate {
+	buckets := newReadingBuckets(start, end, bucket)
+	for _, r := range readings {
+		buckets.add(r)
+	}
+	return buckets.aggregates(fns)
+}
+
+// Running values of the buckets of a window, only the non-empty ones are kept
+type readingBuckets struct {
+	start   int64
+	end     int64
+	bucket  int64
+	buckets map[int64]*aggregateBucket
+}
+
+func newReadingBuckets(start int64, end int64, bucket int64) *readingBuckets {
+	return &readingBuckets{start: start, end: end, bucket: bucket, buckets: make(map[int64]*aggregateBucket)}
+}
+
+// Add a reading to its bucket, those not numeric or with origin out of the window are ignored
+func (rb *readingBuckets) add(r contract.Reading) {
+	origin := r.Origin
+	if origin == 0 {
+		origin = r.Created
+	}
+	if origin < rb.start || origin >= rb.end {
+		return
+	}
+
+	value, err := strconv.ParseFloat(r.Value, 64)
+	if err != nil {
+		return
+	}
+
+	key := origin - origin%rb.bucket
+	b, ok := rb.buckets[key]
+	if !ok {
+		b = &aggregateBucket{min: value, max: value, last: value, lastOrigin: origin}
+		rb.buckets[key] = b
+	}
+	b.count++
+	b.sum += value
+	b.min = math.Min(b.min, value)
+	b.max = math.Max(b.max, value)
+	if origin >= b.lastOrigin {
+		b.last = value
+		b.lastOrigin = origin
+	}
+}
+
+// Apply the aggregation functions to the buckets, sorted by start
+func (rb *readingBuckets) aggregates(fns []string) []ReadingAggregate {
+	keys := make([]int64, 0, len(rb.buckets))
+	for key := range rb.buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	aggregates := make([]ReadingAggregate, 0, len(keys))
+	for _, key := range keys {
+		b := rb.buckets[key]
+		a := ReadingAggregate{Start: key, End: key + rb.bucket}
+		for _, fn := range fns {
+			switch fn {
+			case aggregateAvg:
+				avg := b.sum / float64(b.count)
+				a.Avg = &avg
+			case aggregateMin:
+				a.Min = &b.min
+			case aggregateMax:
+				a.Max = &b.max
+			case aggregateSum:
+				a.Sum = &b.sum
+			case aggregateCount:
+				a.Count = &b.count
+			case aggregateLast:
+				a.Last = &b.last
+			}
+		}
+		aggregates = append(aggregates, a)
+	}
+	return aggregates
+}
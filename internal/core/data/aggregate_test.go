@@ -0,0 +1,214 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	dbMock "github.com/Circutor/edgex/internal/core/data/interfaces/mocks"
+	"github.com/Circutor/edgex/internal/pkg/db"
+	"github.com/Circutor/edgex/internal/pkg/db/bolt"
+	"github.com/Circutor/edgex/pkg/models"
+)
+
+func TestParseBucket(t *testing.T) {
+	tests := []struct {
+		bucket   string
+		expected int64
+		fails    bool
+	}{
+		{"15m", 15 * 60 * 1000, false},
+		{"1h", 60 * 60 * 1000, false},
+		{"1d", 24 * 60 * 60 * 1000, false},
+		{"", 0, true},
+		{"0s", 0, true},
+		{"xd", 0, true},
+		{"-1h", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bucket, func(t *testing.T) {
+			bucket, err := parseBucket(tt.bucket)
+			if tt.fails {
+				if err == nil {
+					t.Errorf("Bucket %s should not be valid", tt.bucket)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf(err.Error())
+			}
+			if bucket != tt.expected {
+				t.Errorf("Bucket %s should be %d ms, not %d", tt.bucket, tt.expected, bucket)
+			}
+		})
+	}
+}
+
+func TestParseAggregateFuncs(t *testing.T) {
+	fns, err := parseAggregateFuncs("")
+	if err != nil || len(fns) != len(aggregateFuncs) {
+		t.Errorf("All the functions should be returned by default")
+	}
+
+	fns, err = parseAggregateFuncs("avg, max")
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if len(fns) != 2 || fns[0] != aggregateAvg || fns[1] != aggregateMax {
+		t.Errorf("Unexpected functions %v", fns)
+	}
+
+	_, err = parseAggregateFuncs("avg,median")
+	if err == nil {
+		t.Errorf("Function median should not be valid")
+	}
+}
+
+func TestBucketReadings(t *testing.T) {
+	readings := []models.Reading{
+		{Origin: 1000, Value: "1"},
+		{Origin: 1500, Value: "3"},
+		{Origin: 1200, Value: "2"},
+		{Origin: 2500, Value: "10"},
+		{Origin: 2600, Value: "true"},
+		{Origin: 0, Created: 3100, Value: "5"},
+		{Origin: 4000, Value: "7"},
+		{Origin: 500, Value: "9"},
+	}
+
+	aggregates := bucketReadings(readings, 1000, 4000, 1000, aggregateFuncs)
+	if len(aggregates) != 3 {
+		t.Fatalf("There should be 3 buckets, not %d", len(aggregates))
+	}
+
+	a := aggregates[0]
+	if a.Start != 1000 || a.End != 2000 {
+		t.Errorf("Unexpected bucket [%d, %d)", a.Start, a.End)
+	}
+	if *a.Count != 3 || *a.Sum != 6 || *a.Avg != 2 || *a.Min != 1 || *a.Max != 3 || *a.Last != 3 {
+		t.Errorf("Unexpected aggregate count %d sum %f avg %f min %f max %f last %f",
+			*a.Count, *a.Sum, *a.Avg, *a.Min, *a.Max, *a.Last)
+	}
+
+	if aggregates[1].Start != 2000 || *aggregates[1].Count != 1 {
+		t.Errorf("Non numeric readings should be ignored")
+	}
+
+	if aggregates[2].Start != 3000 || *aggregates[2].Last != 5 {
+		t.Errorf("The creation time should be used when there is no origin")
+	}
+}
+
+func TestBucketReadingsSelectedFuncs(t *testing.T) {
+	readings := []models.Reading{{Origin: 1000, Value: "1"}}
+
+	aggregates := bucketReadings(readings, 0, math.MaxInt64, 1000, []string{aggregateMax})
+	if len(aggregates) != 1 {
+		t.Fatalf("There should be 1 bucket, not %d", len(aggregates))
+	}
+
+	a := aggregates[0]
+	if a.Max == nil || *a.Max != 1 {
+		t.Errorf("Max should be 1")
+	}
+	if a.Avg != nil || a.Min != nil || a.Sum != nil || a.Count != nil || a.Last != nil {
+		t.Errorf("Only max should be returned")
+	}
+}
+
+func TestAggregateReadingsDBThrowsError(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("ReadingsByDeviceAndName", "valid", "Pressure", int64(0), int64(math.MaxInt64), 0, aggregatePageSize, db.OldestFirst).Return(nil, fmt.Errorf("some error"))
+	dbClient = myMock
+
+	_, err := aggregateReadings("valid", "Pressure", 0, 1000, 100, aggregateFuncs)
+	if err == nil {
+		t.Errorf("Expected error aggregating readings")
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestAggregateReadingsBolt(t *testing.T) {
+	reset()
+	dir, err := ioutil.TempDir("", "coredata")
+	if err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := bolt.NewClient(db.Configuration{DatabaseName: filepath.Join(dir, "coredata.db")})
+	if err != nil {
+		t.Fatalf("Could not open BoltDB: %v", err)
+	}
+	defer client.CloseSession()
+	dbClient = client
+
+	readings := []models.Reading{
+		{Device: "valid", Name: "Pressure", Value: "1", Origin: 1000},
+		{Device: "valid", Name: "Pressure", Value: "3", Origin: 1500},
+		{Device: "valid", Name: "Temperature", Value: "20", Origin: 1500},
+		{Device: "valid", Name: "Pressure", Value: "5", Origin: 2500},
+	}
+	_, err = client.AddEvent(models.Event{Device: "valid", Readings: readings})
+	if err != nil {
+		t.Fatalf("Error adding event: %v", err)
+	}
+
+	aggregates, err := aggregateReadings("valid", "Pressure", 0, db.MakeTimestamp()+1000, 1000, aggregateFuncs)
+	if err != nil {
+		t.Fatalf("Error aggregating readings: %v", err)
+	}
+	if len(aggregates) != 2 {
+		t.Fatalf("There should be 2 buckets, not %d", len(aggregates))
+	}
+	if *aggregates[0].Count != 2 || *aggregates[0].Avg != 2 || *aggregates[1].Last != 5 {
+		t.Errorf("Invalid aggregates of the stored readings")
+	}
+
+	// The readings are created after the end of the window of their origin
+	aggregates, err = aggregateReadings("valid", "Pressure", 0, 2000, 1000, aggregateFuncs)
+	if err != nil || len(aggregates) != 1 || *aggregates[0].Count != 2 {
+		t.Errorf("Readings back-filled should be aggregated with their origin: %v", err)
+	}
+
+	aggregates, err = aggregateReadings("valid", "Pressure", 0, 1, 1000, aggregateFuncs)
+	if err != nil || len(aggregates) != 0 {
+		t.Errorf("Readings with origin after the end should not be aggregated")
+	}
+}
+
+func TestAggregateReadingsPages(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	page := make([]models.Reading, aggregatePageSize)
+	for i := range page {
+		page[i] = models.Reading{Value: "1", Origin: 500, Created: 1000 + int64(i/2)}
+	}
+	last := page[aggregatePageSize-1].Created
+	next := []models.Reading{{Value: "2", Origin: 1500, Created: last}}
+	myMock.On("ReadingsByDeviceAndName", "valid", "Pressure", int64(0), int64(math.MaxInt64), 0, aggregatePageSize, db.OldestFirst).Return(page, nil)
+	myMock.On("ReadingsByDeviceAndName", "valid", "Pressure", last, int64(math.MaxInt64), 2, aggregatePageSize, db.OldestFirst).Return(next, nil)
+	dbClient = myMock
+
+	// Each page goes on after the readings created at the same time already read
+	aggregates, err := aggregateReadings("valid", "Pressure", 0, 2000, 1000, []string{aggregateCount})
+	if err != nil || len(aggregates) != 2 {
+		t.Fatalf("There should be 2 buckets: %v %v", aggregates, err)
+	}
+	if *aggregates[0].Count != aggregatePageSize || *aggregates[1].Count != 1 {
+		t.Errorf("Every reading should be aggregated once")
+	}
+	myMock.AssertExpectations(t)
+}
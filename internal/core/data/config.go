@@ -21,6 +21,7 @@ type ConfigurationStruct struct {
 	Clients      map[string]config.ClientInfo
 	Databases    map[string]config.DatabaseInfo
 	Logging      config.LoggingInfo
+	Retention    RetentionInfo
 	Service      config.ServiceInfo
 }
 
@@ -31,3 +32,17 @@ type WritableInfo struct {
 	ServiceUpdateLastConnected bool
 	LogLevel                   string
 }
+
+// RetentionInfo defines the policy used to purge old events. Any limit set to 0 is disabled.
+type RetentionInfo struct {
+	// Interval, in milliseconds, between two runs of the policy. 0 disables the purge.
+	Interval int
+	// MaxAge, in milliseconds, of the events kept in the database.
+	MaxAge int64
+	// MaxEvents is the maximum number of events kept in the database.
+	MaxEvents int
+	// MaxDbSize is the maximum number of bytes used by the database.
+	MaxDbSize int64
+	// OnlyPushed keeps the events that haven't been exported yet.
+	OnlyPushed bool
+}
@@ -31,6 +31,18 @@ func NewErrEventNotFound(id string) error {
 	return &ErrEventNotFound{id: id}
 }
 
+type ErrReadingNotFound struct {
+	id string
+}
+
+func (e ErrReadingNotFound) Error() string {
+	return fmt.Sprintf("no reading found for id %s", e.id)
+}
+
+func NewErrReadingNotFound(id string) error {
+	return &ErrReadingNotFound{id: id}
+}
+
 type ErrValueDescriptorInvalid struct {
 	name string
 	err  error
@@ -68,6 +80,18 @@ func NewErrUnsupportedPublisher(pubType string) error {
 	return &ErrUnsupportedPublisher{pubType: pubType}
 }
 
+type ErrNoReceiver struct {
+	pubType string
+}
+
+func (e ErrNoReceiver) Error() string {
+	return fmt.Sprintf("publisher type '%s' needs export-distro in the same process", e.pubType)
+}
+
+func NewErrNoReceiver(pubType string) error {
+	return &ErrNoReceiver{pubType: pubType}
+}
+
 type ErrLimitExceeded struct {
 	limit int
 }
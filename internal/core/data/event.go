@@ -16,14 +16,21 @@ package data
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
+	"sync"
 
 	"github.com/Circutor/edgex/internal/core/data/errors"
 	"github.com/Circutor/edgex/internal/pkg/correlation"
 	"github.com/Circutor/edgex/internal/pkg/correlation/models"
 	"github.com/Circutor/edgex/internal/pkg/db"
+	"github.com/Circutor/edgex/pkg/clients/types"
 	contract "github.com/Circutor/edgex/pkg/models"
 )
 
+// Serializes the updates of the push dates
+var pushMutex sync.Mutex
+
 func countEvents() (int, error) {
 	count, err := dbClient.EventCount()
 	if err != nil {
@@ -46,18 +53,10 @@ func countEventsByDevice(device string, ctx context.Context) (int, error) {
 }
 
 func deleteEventsByAge(age int64) (int, error) {
-	events, err := dbClient.EventsOlderThanAge(age)
+	count, err := dbClient.DeleteEventsOlderThan(age)
 	if err != nil {
 		return -1, err
 	}
-
-	// Delete all the events
-	count := len(events)
-	for _, event := range events {
-		if err = deleteEvent(event); err != nil {
-			return -1, err
-		}
-	}
 	return count, nil
 }
 
@@ -99,6 +98,58 @@ func addNewEvent(e contract.Event, ctx context.Context) (string, error) {
 	return e.ID, nil
 }
 
+// Add a batch of events persisting the valid ones in a single transaction
+// Return the status of each event in the same order they were received
+func addNewEvents(events []contract.Event, ctx context.Context) ([]contract.EventBatchStatus, error) {
+	statuses := make([]contract.EventBatchStatus, len(events))
+
+	// Check the devices once per batch
+	checked := make(map[string]error)
+	var valid []contract.Event
+	var positions []int
+	for i, e := range events {
+		err, ok := checked[e.Device]
+		if !ok {
+			err = checkDevice(e.Device, ctx)
+			checked[e.Device] = err
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if x, ok := err.(*types.ErrServiceClient); ok {
+				status = x.StatusCode
+			}
+			statuses[i] = contract.EventBatchStatus{Status: status, Error: err.Error()}
+			continue
+		}
+		valid = append(valid, e)
+		positions = append(positions, i)
+	}
+
+	// Add the events and readings to the database
+	if Configuration.Writable.PersistData && len(valid) > 0 {
+		ids, err := dbClient.AddEvents(valid)
+		if err != nil {
+			return nil, err
+		}
+		for i := range valid {
+			valid[i].ID = ids[i]
+		}
+	}
+
+	reported := make(map[string]bool)
+	for i, e := range valid {
+		putEventOnQueue(e, ctx) // Push the aux struct to export service (It has the actual readings)
+		if !reported[e.Device] {
+			reported[e.Device] = true
+			chEvents <- DeviceLastReported{e.Device}        // update last reported connected (device)
+			chEvents <- DeviceServiceLastReported{e.Device} // update last reported connected (device service)
+		}
+		statuses[positions[i]] = contract.EventBatchStatus{ID: e.ID, Status: http.StatusOK}
+	}
+
+	return statuses, nil
+}
+
 func updateEvent(from contract.Event, ctx context.Context) error {
 	to, err := dbClient.EventById(from.ID)
 	if err != nil {
@@ -119,6 +170,12 @@ func updateEvent(from contract.Event, ctx context.Context) error {
 	if from.Pushed != 0 {
 		to.Pushed = from.Pushed
 	}
+	for registration, pushed := range from.PushedTo {
+		if to.PushedTo == nil {
+			to.PushedTo = make(map[string]int64)
+		}
+		to.PushedTo[registration] = pushed
+	}
 	if from.Origin != 0 {
 		to.Origin = from.Origin
 	}
@@ -158,13 +215,21 @@ func getEventById(id string) (contract.Event, error) {
 	return e, nil
 }
 
-func updateEventPushDate(id string, ctx context.Context) error {
+// Set the push date of the event, and the push date for the registration if it is not empty
+func updateEventPushDate(id string, registration string, ctx context.Context) error {
+	// Registrations push the same event concurrently, do not lose their updates
+	pushMutex.Lock()
+	defer pushMutex.Unlock()
+
 	e, err := getEventById(id)
 	if err != nil {
 		return err
 	}
 
 	e.Pushed = db.MakeTimestamp()
+	if registration != "" {
+		e.PushedTo = map[string]int64{registration: e.Pushed}
+	}
 	err = updateEvent(e, ctx)
 	if err != nil {
 		return err
@@ -185,8 +250,15 @@ func putEventOnQueue(e contract.Event, ctx context.Context) {
 	}
 }
 
-func getUnspushedEventsLimit(limit int) ([]contract.Event, error) {
-	eventList, err := dbClient.EventsUnpushedLimit(limit)
+// Get the events not pushed yet, only by the given registration if it is not empty
+func getUnspushedEventsLimit(limit int, registration string) ([]contract.Event, error) {
+	var eventList []contract.Event
+	var err error
+	if registration == "" {
+		eventList, err = dbClient.EventsUnpushedLimit(limit)
+	} else {
+		eventList, err = dbClient.EventsUnpushedByRegistrationLimit(registration, limit)
+	}
 	if err != nil {
 		LoggingClient.Error(err.Error())
 		return nil, err
@@ -195,8 +267,8 @@ func getUnspushedEventsLimit(limit int) ([]contract.Event, error) {
 	return eventList, nil
 }
 
-func getEventsByDeviceIdLimit(limit int, deviceId string) ([]contract.Event, error) {
-	eventList, err := dbClient.EventsForDeviceLimit(deviceId, limit)
+func getEventsByDeviceIdLimit(limit int, deviceId string, order db.SortOrder) ([]contract.Event, error) {
+	eventList, err := dbClient.EventsForDeviceLimit(deviceId, limit, order)
 	if err != nil {
 		LoggingClient.Error(err.Error())
 		return nil, err
@@ -205,8 +277,8 @@ func getEventsByDeviceIdLimit(limit int, deviceId string) ([]contract.Event, err
 	return eventList, nil
 }
 
-func getEventsByCreationTime(limit int, start int64, end int64) ([]contract.Event, error) {
-	eventList, err := dbClient.EventsByCreationTime(start, end, limit)
+func getEventsByCreationTime(limit int, start int64, end int64, order db.SortOrder) ([]contract.Event, error) {
+	eventList, err := dbClient.EventsByCreationTime(start, end, limit, order)
 	if err != nil {
 		LoggingClient.Error(err.Error())
 		return nil, err
@@ -216,73 +288,36 @@ func getEventsByCreationTime(limit int, start int64, end int64) ([]contract.Even
 }
 
 func getReadingsByDeviceId(limit int, deviceId string, valueDescriptor string) ([]contract.Reading, error) {
-	eventList, err := dbClient.EventsForDevice(deviceId)
+	// Only pick the readings who match the value descriptor
+	readings, err := dbClient.ReadingsByDeviceAndName(deviceId, valueDescriptor, 0, math.MaxInt64, 0, limit, db.OldestFirst)
 	if err != nil {
 		LoggingClient.Error(err.Error())
 		return nil, err
 	}
 
-	// Only pick the readings who match the value descriptor
-	var readings []contract.Reading
-	count := 0 // Make sure we stay below the limit
-	for _, event := range eventList {
-		if count >= limit {
-			break
-		}
-		for _, reading := range event.Readings {
-			if count >= limit {
-				break
-			}
-			if reading.Name == valueDescriptor {
-				readings = append(readings, reading)
-				count += 1
-			}
-		}
-	}
-
 	return readings, nil
 }
 
 func deleteEvents(deviceId string) (int, error) {
-	// Get the events by the device name
-	events, err := dbClient.EventsForDevice(deviceId)
+	LoggingClient.Info("Deleting the events for device: " + deviceId)
+
+	count, err := dbClient.DeleteEventsByDevice(deviceId)
 	if err != nil {
 		LoggingClient.Error(err.Error())
 		return 0, err
 	}
 
-	LoggingClient.Info("Deleting the events for device: " + deviceId)
-
-	// Delete the events
-	count := len(events)
-	for _, event := range events {
-		if err = deleteEvent(event); err != nil {
-			LoggingClient.Error(err.Error())
-			return 0, err
-		}
-	}
-
 	return count, nil
 }
 
 func scrubPushedEvents() (int, error) {
 	LoggingClient.Info("Scrubbing events.  Deleting all events that have been pushed")
 
-	// Get the events
-	events, err := dbClient.EventsPushed()
+	count, err := dbClient.DeletePushedEvents()
 	if err != nil {
 		LoggingClient.Error(err.Error())
 		return 0, err
 	}
 
-	// Delete all the events
-	count := len(events)
-	for _, event := range events {
-		if err = deleteEvent(event); err != nil {
-			LoggingClient.Error(err.Error())
-			return 0, err
-		}
-	}
-
 	return count, nil
 }
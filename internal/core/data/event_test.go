@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"strconv"
 	"sync"
 	"testing"
@@ -25,6 +26,8 @@ import (
 	"github.com/Circutor/edgex/internal/core/data/errors"
 	dbMock "github.com/Circutor/edgex/internal/core/data/interfaces/mocks"
 	"github.com/Circutor/edgex/internal/pkg/db"
+	"github.com/Circutor/edgex/pkg/clients/metadata/mocks"
+	"github.com/Circutor/edgex/pkg/clients/types"
 	"github.com/Circutor/edgex/pkg/models"
 
 	"github.com/globalsign/mgo/bson"
@@ -82,13 +85,9 @@ func buildEvents() []models.Event {
 func newDeleteEventsOlderThanAgeMockDB() *dbMock.DBClient {
 	myMock := &dbMock.DBClient{}
 
-	myMock.On("EventsOlderThanAge", mock.MatchedBy(func(age int64) bool {
+	myMock.On("DeleteEventsOlderThan", mock.MatchedBy(func(age int64) bool {
 		return age == -1
-	})).Return(buildEvents(), nil).Maybe()
-
-	myMock.On("DeleteEventById", mock.MatchedBy(func(id string) bool {
-		return id == "1"
-	})).Return(nil)
+	})).Return(len(buildEvents()), nil)
 
 	return myMock
 }
@@ -109,13 +108,13 @@ func TestDeleteByAge(t *testing.T) {
 	mockDb.AssertExpectations(t)
 }
 
-func TestDeleteEventByAgeErrorThrownByEventsOlderThanAge(t *testing.T) {
+func TestDeleteEventByAgeErrorThrownByDeleteEventsOlderThan(t *testing.T) {
 	reset()
 	myMock := &dbMock.DBClient{}
 
-	myMock.On("EventsOlderThanAge", mock.MatchedBy(func(age int64) bool {
+	myMock.On("DeleteEventsOlderThan", mock.MatchedBy(func(age int64) bool {
 		return age == -1
-	})).Return([]models.Event{}, fmt.Errorf("some error"))
+	})).Return(0, fmt.Errorf("some error"))
 
 	dbClient = myMock
 
@@ -360,7 +359,7 @@ func TestUpdateEventPushDate(t *testing.T) {
 	})).Return(nil)
 	dbClient = myMock
 
-	err := updateEventPushDate(testEvent.ID, context.Background())
+	err := updateEventPushDate(testEvent.ID, "", context.Background())
 	if err != nil {
 		t.Errorf(err.Error())
 	}
@@ -368,6 +367,42 @@ func TestUpdateEventPushDate(t *testing.T) {
 	myMock.AssertExpectations(t)
 }
 
+func TestUpdateEventPushDateForRegistration(t *testing.T) {
+	reset()
+	pushed := testEvent
+	pushed.PushedTo = map[string]int64{"thingsboard": 1}
+	myMock := &dbMock.DBClient{}
+	myMock.On("EventById", testEvent.ID).Return(pushed, nil)
+	myMock.On("UpdateEvent", mock.MatchedBy(func(event models.Event) bool {
+		return event.Pushed != 0 && event.PushedTo["dexma"] == event.Pushed && event.PushedTo["thingsboard"] == 1
+	})).Return(nil)
+	dbClient = myMock
+
+	err := updateEventPushDate(testEvent.ID, "dexma", context.Background())
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestGetUnpushedEventsByRegistration(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("EventsUnpushedByRegistrationLimit", "dexma", 10).Return([]models.Event{testEvent}, nil)
+	dbClient = myMock
+
+	events, err := getUnspushedEventsLimit(10, "dexma")
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+	if len(events) != 1 {
+		t.Errorf("expected 1 event, received: %d", len(events))
+	}
+
+	myMock.AssertExpectations(t)
+}
+
 func newDeleteEventMockDB() *dbMock.DBClient {
 	myMock := &dbMock.DBClient{}
 	myMock.On("EventById", mock.MatchedBy(func(id string) bool {
@@ -451,11 +486,11 @@ func TestGetEventsByDeviceIdLimit(t *testing.T) {
 
 	myMock.On("EventsForDeviceLimit", mock.MatchedBy(func(deviceId string) bool {
 		return deviceId == "valid"
-	}), mock.Anything).Return([]models.Event{testEvent}, nil)
+	}), mock.Anything, mock.Anything).Return([]models.Event{testEvent}, nil)
 
 	dbClient = myMock
 
-	expectedList, expectedNil := getEventsByDeviceIdLimit(0, "valid")
+	expectedList, expectedNil := getEventsByDeviceIdLimit(0, "valid", db.OldestFirst)
 
 	if expectedNil != nil {
 		t.Errorf("Should not throw error")
@@ -476,11 +511,11 @@ func TestGetEventsByDeviceIdLimitDBThrowsError(t *testing.T) {
 
 	myMock.On("EventsForDeviceLimit", mock.MatchedBy(func(deviceId string) bool {
 		return deviceId == "error"
-	}), mock.Anything).Return(nil, fmt.Errorf("some error"))
+	}), mock.Anything, mock.Anything).Return(nil, fmt.Errorf("some error"))
 
 	dbClient = myMock
 
-	expectedNil, expectedErr := getEventsByDeviceIdLimit(0, "error")
+	expectedNil, expectedErr := getEventsByDeviceIdLimit(0, "error", db.OldestFirst)
 
 	if expectedNil != nil {
 		t.Errorf("Should not return list")
@@ -497,11 +532,11 @@ func TestGetEventsByCreationTime(t *testing.T) {
 
 	myMock.On("EventsByCreationTime", mock.MatchedBy(func(start int64) bool {
 		return start == 0xF00D
-	}), mock.Anything, mock.Anything).Return([]models.Event{}, nil)
+	}), mock.Anything, mock.Anything, mock.Anything).Return([]models.Event{}, nil)
 
 	dbClient = myMock
 
-	expectedReadings, expectedNil := getEventsByCreationTime(0, 0xF00D, 0)
+	expectedReadings, expectedNil := getEventsByCreationTime(0, 0xF00D, 0, db.NewestFirst)
 
 	if expectedReadings == nil {
 		t.Errorf("Should return Events")
@@ -518,11 +553,11 @@ func TestGetEventsByCreationTimeDBThrowsError(t *testing.T) {
 
 	myMock.On("EventsByCreationTime", mock.MatchedBy(func(start int64) bool {
 		return start == 0xBADF00D
-	}), mock.Anything, mock.Anything).Return(nil, fmt.Errorf("some error"))
+	}), mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("some error"))
 
 	dbClient = myMock
 
-	expectedNil, expectedErr := getEventsByCreationTime(0, 0xBADF00D, 0)
+	expectedNil, expectedErr := getEventsByCreationTime(0, 0xBADF00D, 0, db.NewestFirst)
 
 	if expectedNil != nil {
 		t.Errorf("Should not return list")
@@ -537,7 +572,7 @@ func TestGetReadingsByDeviceId(t *testing.T) {
 	reset()
 	myMock := &dbMock.DBClient{}
 
-	myMock.On("EventsForDevice", mock.Anything).Return([]models.Event{{Readings: append(buildReadings(), buildReadings()...)}}, nil)
+	myMock.On("ReadingsByDeviceAndName", "valid", "Pressure", int64(0), int64(math.MaxInt64), 0, math.MaxInt32, db.OldestFirst).Return(buildReadings(), nil)
 
 	dbClient = myMock
 
@@ -558,33 +593,32 @@ func TestGetReadingsByDeviceId(t *testing.T) {
 
 func TestDeleteEvents(t *testing.T) {
 	reset()
-	readings := buildReadings()
 	myMock := &dbMock.DBClient{}
 
-	myMock.On("EventsForDevice", mock.MatchedBy(func(deviceId string) bool {
+	myMock.On("DeleteEventsByDevice", mock.MatchedBy(func(deviceId string) bool {
 		return deviceId == testUUIDString
-	})).Return([]models.Event{{ID: testUUIDString, Readings: readings}}, nil)
-
-	myMock.On("DeleteEventById", mock.MatchedBy(func(eventId string) bool {
-		return eventId == testUUIDString
-	})).Return(nil)
+	})).Return(1, nil)
 
 	dbClient = myMock
 
-	_, expectedNil := deleteEvents(testUUIDString)
+	count, expectedNil := deleteEvents(testUUIDString)
 
 	if expectedNil != nil {
 		t.Errorf("Should not throw error")
 	}
 
+	if count != 1 {
+		t.Errorf("Expected 1 deletion, was %d", count)
+	}
+
 	myMock.AssertExpectations(t)
 }
 
-func TestDeleteEventsDBLookupThrowsError(t *testing.T) {
+func TestDeleteEventsDBThrowsError(t *testing.T) {
 	reset()
 	myMock := &dbMock.DBClient{}
 
-	myMock.On("EventsForDevice", mock.Anything).Return(nil, fmt.Errorf("some error"))
+	myMock.On("DeleteEventsByDevice", mock.Anything).Return(0, fmt.Errorf("some error"))
 
 	dbClient = myMock
 
@@ -602,15 +636,8 @@ func TestDeleteEventsDBLookupThrowsError(t *testing.T) {
 func TestScrubPushedEvents(t *testing.T) {
 	reset()
 
-	pushedEvents := []models.Event{testEvent, testEvent}
-	pushedEvents[1].ID = testUUIDString
-
 	myMock := &dbMock.DBClient{}
-	myMock.On("EventsPushed").Return(pushedEvents, nil)
-
-	myMock.On("DeleteEventById", mock.MatchedBy(func(id string) bool {
-		return id == pushedEvents[0].ID || id == pushedEvents[1].ID
-	})).Return(nil).Twice()
+	myMock.On("DeletePushedEvents").Return(2, nil)
 
 	dbClient = myMock
 
@@ -624,6 +651,8 @@ func TestScrubPushedEvents(t *testing.T) {
 	if expectedNil != nil {
 		t.Errorf("Should not throw error")
 	}
+
+	myMock.AssertExpectations(t)
 }
 
 func testEventWithoutReadings(event models.Event, t *testing.T) {
@@ -639,3 +668,76 @@ func testEventWithoutReadings(event models.Event, t *testing.T) {
 		t.Error("origin mismatch. expected " + strconv.FormatInt(testEvent.Origin, 10) + " received " + strconv.FormatInt(event.Origin, 10))
 	}
 }
+
+func TestAddEventsBatch(t *testing.T) {
+	reset()
+	Configuration.Writable.PersistData = true
+	Configuration.Writable.MetaDataCheck = true
+	defer func() { mdc = newMockDeviceClient() }()
+
+	client := &mocks.DeviceClient{}
+	client.On("CheckForDevice", testDeviceName, context.Background()).Return(models.Device{Name: testDeviceName}, nil).Once()
+	client.On("CheckForDevice", "404", context.Background()).Return(models.Device{},
+		types.NewErrServiceClient(http.StatusNotFound, []byte{})).Once()
+	mdc = client
+
+	myMock := &dbMock.DBClient{}
+	myMock.On("AddEvents", mock.MatchedBy(func(events []models.Event) bool {
+		return len(events) == 2 && events[0].Origin == 1 && events[1].Origin == 3
+	})).Return([]string{"id1", "id3"}, nil)
+	dbClient = myMock
+
+	events := []models.Event{
+		{Device: testDeviceName, Origin: 1},
+		{Device: "404", Origin: 2},
+		{Device: testDeviceName, Origin: 3},
+	}
+
+	bitEvents := make([]bool, 2)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go handleDomainEvents(bitEvents, &wg, t)
+
+	statuses, err := addNewEvents(events, context.Background())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	wg.Wait()
+
+	if len(statuses) != 3 {
+		t.Fatalf("There should be 3 statuses, not %d", len(statuses))
+	}
+	if statuses[0].ID != "id1" || statuses[0].Status != http.StatusOK {
+		t.Errorf("Unexpected status %v", statuses[0])
+	}
+	if statuses[1].ID != "" || statuses[1].Status != http.StatusNotFound {
+		t.Errorf("Unexpected status %v", statuses[1])
+	}
+	if statuses[2].ID != "id3" || statuses[2].Status != http.StatusOK {
+		t.Errorf("Unexpected status %v", statuses[2])
+	}
+	for i, val := range bitEvents {
+		if !val {
+			t.Errorf("event not received in timely fashion, index %v, TestAddEventsBatch", i)
+		}
+	}
+
+	myMock.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestAddEventsBatchDBThrowsError(t *testing.T) {
+	reset()
+	Configuration.Writable.PersistData = true
+	myMock := &dbMock.DBClient{}
+	myMock.On("AddEvents", mock.Anything).Return(nil, fmt.Errorf("some error"))
+	dbClient = myMock
+
+	_, err := addNewEvents([]models.Event{{Device: testDeviceName}}, context.Background())
+	if err == nil {
+		t.Errorf("Expected error adding the events")
+	}
+
+	myMock.AssertExpectations(t)
+}
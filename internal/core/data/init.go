@@ -62,7 +62,15 @@ func Retry(useProfile string, timeout int, wait *sync.WaitGroup, ch chan error)
 				LoggingClient = logger.NewClient(internal.CoreDataServiceKey, Configuration.Logging.EnableRemote, logTarget, Configuration.Writable.LogLevel)
 
 				// Initialize service clients
-				initializeClients()
+				err = initializeClients()
+				if err != nil {
+					ch <- err
+
+					// The configured message bus is not valid. Fail fast.
+					close(ch)
+					wait.Done()
+					return
+				}
 			}
 		}
 
@@ -89,6 +97,7 @@ func Init() bool {
 	}
 	chEvents = make(chan interface{}, 100)
 	initEventHandlers()
+	startRetention()
 
 	go telemetry.StartCpuUsageAverage()
 
@@ -96,6 +105,7 @@ func Init() bool {
 }
 
 func Destruct() {
+	stopRetention()
 	if dbClient != nil {
 		dbClient.CloseSession()
 		dbClient = nil
@@ -146,7 +156,7 @@ func newDBClient(dbType string, config db.Configuration) (interfaces.DBClient, e
 	}
 }
 
-func initializeClients() {
+func initializeClients() error {
 	// Create metadata clients
 	url := Configuration.Clients["Metadata"].Url() + clients.ApiDeviceRoute
 	mdc = metadata.NewDeviceClient(url)
@@ -155,9 +165,15 @@ func initializeClients() {
 	msc = metadata.NewDeviceServiceClient(url)
 
 	// Create the event publisher
-	ep = messaging.NewEventPublisher(messaging.PubSubConfiguration{
-		AddressPort: Configuration.MessageQueue.Uri(),
+	var err error
+	ep, err = messaging.NewEventPublisher(messaging.PubSubConfiguration{
+		AddressPort:    Configuration.MessageQueue.Uri(),
+		Type:           Configuration.MessageQueue.Type,
+		Topic:          Configuration.MessageQueue.Topic,
+		BufferSize:     Configuration.MessageQueue.BufferSize,
+		PublishTimeout: Configuration.MessageQueue.PublishTimeout,
 	})
+	return err
 }
 
 func setLoggingTarget() string {
@@ -14,6 +14,7 @@
 package interfaces
 
 import (
+	"github.com/Circutor/edgex/internal/pkg/db"
 	contract "github.com/Circutor/edgex/pkg/models"
 )
 
@@ -33,6 +34,11 @@ type DBClient interface {
 	// UnexpectedError - failed to add to database
 	AddEvent(e contract.Event) (string, error)
 
+	// Add several events in a single transaction
+	// Return their IDs in the same order
+	// UnexpectedError - failed to add to database, none of the events is added
+	AddEvents(events []contract.Event) ([]string, error)
+
 	// Update an event - do NOT update readings
 	// UnexpectedError - problem updating in database
 	// NotFound - no event with the ID was found
@@ -59,34 +65,77 @@ type DBClient interface {
 	DeleteEventById(id string) error
 
 	// Get a list of events that haven't been pushed yet to export/server based on the limit
+	// Sort the events from the oldest to the newest
 	EventsUnpushedLimit(limit int) ([]contract.Event, error)
 
+	// Get a list of events that haven't been pushed yet by the given export registration based on the limit
+	// Sort the events from the oldest to the newest
+	EventsUnpushedByRegistrationLimit(registration string, limit int) ([]contract.Event, error)
+
 	// Get a list of events based on the device id and limit
-	EventsForDeviceLimit(id string, limit int) ([]contract.Event, error)
+	// Sort the events by creation time in the given order
+	EventsForDeviceLimit(id string, limit int, order db.SortOrder) ([]contract.Event, error)
 
 	// Get a list of events based on the device id
 	EventsForDevice(id string) ([]contract.Event, error)
 
 	// Delete all of the events by the device id (and the readings)
-	//DeleteEventsByDeviceId(id string) error
+	// Return the number of events removed
+	DeleteEventsByDevice(id string) (int, error)
 
 	// Return a list of events whos creation time is between startTime and endTime
-	// Limit the number of results by limit
-	EventsByCreationTime(startTime, endTime int64, limit int) ([]contract.Event, error)
+	// Limit the number of results by limit and sort them in the given order
+	EventsByCreationTime(startTime, endTime int64, limit int, order db.SortOrder) ([]contract.Event, error)
 
 	// Remove all the events that are older than the given age
 	// Return the number of events removed
-	//RemoveEventByAge(age int64) (int, error)
+	DeleteEventsOlderThan(age int64) (int, error)
 
 	// Get events that are older than a age
 	EventsOlderThanAge(age int64) ([]contract.Event, error)
 
 	// Remove all the events that have been pushed
-	//func (dbc *DBClient) ScrubEvents()(int, error)
+	// Return the number of events removed
+	DeletePushedEvents() (int, error)
+
+	// Remove all the events that have been pushed and are older than the given age
+	// Return the number of events removed
+	DeletePushedEventsOlderThan(age int64) (int, error)
+
+	// Remove up to count events starting from the oldest one, keeping the
+	// events that haven't been pushed yet if onlyPushed is set
+	// Return the number of events removed
+	DeleteOldestEvents(count int, onlyPushed bool) (int, error)
+
+	// Get the number of bytes in use by the database
+	DatabaseSize() (int64, error)
 
 	// Get events that have been pushed (pushed field is not 0)
 	EventsPushed() ([]contract.Event, error)
 
 	// Delete all readings and events
 	ScrubAllEvents() error
+
+	// ********************** READING FUNCTIONS *****************************
+	// Return readings up to the number specified skipping the first offset ones
+	// Sort the readings by creation time in the given order
+	Readings(offset, limit int, order db.SortOrder) ([]contract.Reading, error)
+
+	// Get a reading by id
+	ReadingById(id string) (contract.Reading, error)
+
+	// Get the number of readings in Core Data
+	ReadingCount() (int, error)
+
+	// Get the readings of the device specified by id
+	ReadingsByDevice(id string, offset, limit int, order db.SortOrder) ([]contract.Reading, error)
+
+	// Get the readings with the given name (value descriptor)
+	ReadingsByName(name string, offset, limit int, order db.SortOrder) ([]contract.Reading, error)
+
+	// Get the readings of a device with the given name whose creation time is between startTime and endTime
+	ReadingsByDeviceAndName(id, name string, startTime, endTime int64, offset, limit int, order db.SortOrder) ([]contract.Reading, error)
+
+	// Get the readings whose creation time is between startTime and endTime
+	ReadingsByCreationTime(startTime, endTime int64, offset, limit int, order db.SortOrder) ([]contract.Reading, error)
 }
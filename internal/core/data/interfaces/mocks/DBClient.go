@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	"github.com/Circutor/edgex/internal/pkg/db"
 	"github.com/Circutor/edgex/pkg/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -33,6 +34,29 @@ func (_m *DBClient) AddEvent(e models.Event) (string, error) {
 	return r0, r1
 }
 
+// AddEvents provides a mock function with given fields: events
+func (_m *DBClient) AddEvents(events []models.Event) ([]string, error) {
+	ret := _m.Called(events)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func([]models.Event) []string); ok {
+		r0 = rf(events)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]models.Event) error); ok {
+		r1 = rf(events)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CloseSession provides a mock function with given fields:
 func (_m *DBClient) CloseSession() {
 	_m.Called()
@@ -52,6 +76,27 @@ func (_m *DBClient) Connect() error {
 	return r0
 }
 
+// DatabaseSize provides a mock function with given fields:
+func (_m *DBClient) DatabaseSize() (int64, error) {
+	ret := _m.Called()
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteEventById provides a mock function with given fields: id
 func (_m *DBClient) DeleteEventById(id string) error {
 	ret := _m.Called(id)
@@ -66,6 +111,111 @@ func (_m *DBClient) DeleteEventById(id string) error {
 	return r0
 }
 
+// DeleteEventsByDevice provides a mock function with given fields: id
+func (_m *DBClient) DeleteEventsByDevice(id string) (int, error) {
+	ret := _m.Called(id)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(string) int); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteEventsOlderThan provides a mock function with given fields: age
+func (_m *DBClient) DeleteEventsOlderThan(age int64) (int, error) {
+	ret := _m.Called(age)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(int64) int); ok {
+		r0 = rf(age)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(age)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteOldestEvents provides a mock function with given fields: count, onlyPushed
+func (_m *DBClient) DeleteOldestEvents(count int, onlyPushed bool) (int, error) {
+	ret := _m.Called(count, onlyPushed)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(int, bool) int); ok {
+		r0 = rf(count, onlyPushed)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, bool) error); ok {
+		r1 = rf(count, onlyPushed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeletePushedEvents provides a mock function with given fields:
+func (_m *DBClient) DeletePushedEvents() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeletePushedEventsOlderThan provides a mock function with given fields: age
+func (_m *DBClient) DeletePushedEventsOlderThan(age int64) (int, error) {
+	ret := _m.Called(age)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(int64) int); ok {
+		r0 = rf(age)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(age)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EventById provides a mock function with given fields: id
 func (_m *DBClient) EventById(id string) (models.Event, error) {
 	ret := _m.Called(id)
@@ -152,13 +302,13 @@ func (_m *DBClient) Events() ([]models.Event, error) {
 	return r0, r1
 }
 
-// EventsByCreationTime provides a mock function with given fields: startTime, endTime, limit
-func (_m *DBClient) EventsByCreationTime(startTime int64, endTime int64, limit int) ([]models.Event, error) {
-	ret := _m.Called(startTime, endTime, limit)
+// EventsByCreationTime provides a mock function with given fields: startTime, endTime, limit, order
+func (_m *DBClient) EventsByCreationTime(startTime int64, endTime int64, limit int, order db.SortOrder) ([]models.Event, error) {
+	ret := _m.Called(startTime, endTime, limit, order)
 
 	var r0 []models.Event
-	if rf, ok := ret.Get(0).(func(int64, int64, int) []models.Event); ok {
-		r0 = rf(startTime, endTime, limit)
+	if rf, ok := ret.Get(0).(func(int64, int64, int, db.SortOrder) []models.Event); ok {
+		r0 = rf(startTime, endTime, limit, order)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Event)
@@ -166,8 +316,8 @@ func (_m *DBClient) EventsByCreationTime(startTime int64, endTime int64, limit i
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(int64, int64, int) error); ok {
-		r1 = rf(startTime, endTime, limit)
+	if rf, ok := ret.Get(1).(func(int64, int64, int, db.SortOrder) error); ok {
+		r1 = rf(startTime, endTime, limit, order)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -198,13 +348,13 @@ func (_m *DBClient) EventsForDevice(id string) ([]models.Event, error) {
 	return r0, r1
 }
 
-// EventsForDeviceLimit provides a mock function with given fields: id, limit
-func (_m *DBClient) EventsForDeviceLimit(id string, limit int) ([]models.Event, error) {
-	ret := _m.Called(id, limit)
+// EventsForDeviceLimit provides a mock function with given fields: id, limit, order
+func (_m *DBClient) EventsForDeviceLimit(id string, limit int, order db.SortOrder) ([]models.Event, error) {
+	ret := _m.Called(id, limit, order)
 
 	var r0 []models.Event
-	if rf, ok := ret.Get(0).(func(string, int) []models.Event); ok {
-		r0 = rf(id, limit)
+	if rf, ok := ret.Get(0).(func(string, int, db.SortOrder) []models.Event); ok {
+		r0 = rf(id, limit, order)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]models.Event)
@@ -212,8 +362,8 @@ func (_m *DBClient) EventsForDeviceLimit(id string, limit int) ([]models.Event,
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string, int) error); ok {
-		r1 = rf(id, limit)
+	if rf, ok := ret.Get(1).(func(string, int, db.SortOrder) error); ok {
+		r1 = rf(id, limit, order)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -267,6 +417,29 @@ func (_m *DBClient) EventsPushed() ([]models.Event, error) {
 	return r0, r1
 }
 
+// EventsUnpushedByRegistrationLimit provides a mock function with given fields: registration, limit
+func (_m *DBClient) EventsUnpushedByRegistrationLimit(registration string, limit int) ([]models.Event, error) {
+	ret := _m.Called(registration, limit)
+
+	var r0 []models.Event
+	if rf, ok := ret.Get(0).(func(string, int) []models.Event); ok {
+		r0 = rf(registration, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Event)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(registration, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // EventsUnpushedLimit provides a mock function with given fields: limit
 // ** Empty mock and no test associated due to the fact that EventsPushed already checks the pushed query
 func (_m *DBClient) EventsUnpushedLimit(limit int) ([]models.Event, error) {
@@ -296,6 +469,163 @@ func (_m *DBClient) EventsWithLimit(limit int) ([]models.Event, error) {
 	return r0, r1
 }
 
+// ReadingById provides a mock function with given fields: id
+func (_m *DBClient) ReadingById(id string) (models.Reading, error) {
+	ret := _m.Called(id)
+
+	var r0 models.Reading
+	if rf, ok := ret.Get(0).(func(string) models.Reading); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(models.Reading)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadingCount provides a mock function with given fields:
+func (_m *DBClient) ReadingCount() (int, error) {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Readings provides a mock function with given fields: offset, limit, order
+func (_m *DBClient) Readings(offset int, limit int, order db.SortOrder) ([]models.Reading, error) {
+	ret := _m.Called(offset, limit, order)
+
+	var r0 []models.Reading
+	if rf, ok := ret.Get(0).(func(int, int, db.SortOrder) []models.Reading); ok {
+		r0 = rf(offset, limit, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reading)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, int, db.SortOrder) error); ok {
+		r1 = rf(offset, limit, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadingsByCreationTime provides a mock function with given fields: startTime, endTime, offset, limit, order
+func (_m *DBClient) ReadingsByCreationTime(startTime int64, endTime int64, offset int, limit int, order db.SortOrder) ([]models.Reading, error) {
+	ret := _m.Called(startTime, endTime, offset, limit, order)
+
+	var r0 []models.Reading
+	if rf, ok := ret.Get(0).(func(int64, int64, int, int, db.SortOrder) []models.Reading); ok {
+		r0 = rf(startTime, endTime, offset, limit, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reading)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64, int64, int, int, db.SortOrder) error); ok {
+		r1 = rf(startTime, endTime, offset, limit, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadingsByDevice provides a mock function with given fields: id, offset, limit, order
+func (_m *DBClient) ReadingsByDevice(id string, offset int, limit int, order db.SortOrder) ([]models.Reading, error) {
+	ret := _m.Called(id, offset, limit, order)
+
+	var r0 []models.Reading
+	if rf, ok := ret.Get(0).(func(string, int, int, db.SortOrder) []models.Reading); ok {
+		r0 = rf(id, offset, limit, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reading)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int, int, db.SortOrder) error); ok {
+		r1 = rf(id, offset, limit, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadingsByDeviceAndName provides a mock function with given fields: id, name, startTime, endTime, offset, limit, order
+func (_m *DBClient) ReadingsByDeviceAndName(id string, name string, startTime int64, endTime int64, offset int, limit int, order db.SortOrder) ([]models.Reading, error) {
+	ret := _m.Called(id, name, startTime, endTime, offset, limit, order)
+
+	var r0 []models.Reading
+	if rf, ok := ret.Get(0).(func(string, string, int64, int64, int, int, db.SortOrder) []models.Reading); ok {
+		r0 = rf(id, name, startTime, endTime, offset, limit, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reading)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, int64, int64, int, int, db.SortOrder) error); ok {
+		r1 = rf(id, name, startTime, endTime, offset, limit, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReadingsByName provides a mock function with given fields: name, offset, limit, order
+func (_m *DBClient) ReadingsByName(name string, offset int, limit int, order db.SortOrder) ([]models.Reading, error) {
+	ret := _m.Called(name, offset, limit, order)
+
+	var r0 []models.Reading
+	if rf, ok := ret.Get(0).(func(string, int, int, db.SortOrder) []models.Reading); ok {
+		r0 = rf(name, offset, limit, order)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Reading)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int, int, db.SortOrder) error); ok {
+		r1 = rf(name, offset, limit, order)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ScrubAllEvents provides a mock function with given fields:
 func (_m *DBClient) ScrubAllEvents() error {
 	ret := _m.Called()
@@ -0,0 +1,33 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package messaging
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/bus"
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+// In-process channel implementation of the event publisher
+type channelEventPublisher struct {
+}
+
+func newChannelEventPublisher(config PubSubConfiguration) EventPublisher {
+	bus.Configure(config.BufferSize, time.Duration(config.PublishTimeout)*time.Millisecond)
+	return &channelEventPublisher{}
+}
+
+// Hand the event to the receiver in the same process, without encoding it
+func (cep *channelEventPublisher) SendEventMessage(e models.Event) error {
+	if !bus.Publish(&e) {
+		return errors.New("in-process bus is full, event dropped")
+	}
+	return nil
+}
@@ -14,18 +14,43 @@
 package messaging
 
 import (
+	"github.com/Circutor/edgex/internal/core/data/errors"
+	"github.com/Circutor/edgex/internal/pkg/bus"
 	"github.com/Circutor/edgex/internal/pkg/correlation/models"
 )
 
 // Configuration struct for PubSub
 type PubSubConfiguration struct {
 	AddressPort string
+	Type        string // Message bus type, mangos by default
+	Topic       string // Topic of the events on the buses that have them
+
+	// In-process channel settings
+	BufferSize     int // Capacity of the channel
+	PublishTimeout int // Milliseconds to wait while the channel is full before dropping the event
 }
 
 type EventPublisher interface {
 	SendEventMessage(e models.Event) error
 }
 
-func NewEventPublisher(conf PubSubConfiguration) EventPublisher {
-	return newMangosEventPublisher(conf)
+// Create the event publisher of the configured message bus type
+func NewEventPublisher(conf PubSubConfiguration) (EventPublisher, error) {
+	switch conf.Type {
+	case "", bus.ZeroMQ, bus.Mangos:
+		return newMangosEventPublisher(conf), nil
+	case bus.Channel:
+		// Nothing would receive the events outside the all-in-one binary
+		if !bus.HasReceiver() {
+			return nil, errors.NewErrNoReceiver(conf.Type)
+		}
+		return newChannelEventPublisher(conf), nil
+	case bus.MQTT:
+		if conf.Topic == "" {
+			conf.Topic = bus.DefaultTopic
+		}
+		return newMqttEventPublisher(conf), nil
+	default:
+		return nil, errors.NewErrUnsupportedPublisher(conf.Type)
+	}
 }
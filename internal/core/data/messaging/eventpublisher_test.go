@@ -0,0 +1,52 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package messaging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/bus"
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+func TestNewEventPublisherUnsupported(t *testing.T) {
+	_, err := NewEventPublisher(PubSubConfiguration{Type: "unknown"})
+	if err == nil {
+		t.Errorf("Publisher type unknown should not be supported")
+	}
+}
+
+func TestChannelEventPublisher(t *testing.T) {
+	if _, err := NewEventPublisher(PubSubConfiguration{Type: bus.Channel}); err == nil {
+		t.Errorf("The channel publisher should need a receiver in the same process")
+	}
+
+	bus.SetReceiver(true)
+	defer bus.SetReceiver(false)
+	ep, err := NewEventPublisher(PubSubConfiguration{Type: bus.Channel})
+	if err != nil {
+		t.Fatalf("Error creating the publisher: %v", err)
+	}
+
+	e := models.Event{CorrelationId: "correlation"}
+	e.ID = "id"
+	err = ep.SendEventMessage(e)
+	if err != nil {
+		t.Fatalf("Error sending the event: %v", err)
+	}
+
+	select {
+	case received := <-bus.Events():
+		if received.ID != e.ID || received.CorrelationId != e.CorrelationId {
+			t.Errorf("Unexpected event %v", received)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Event not received")
+	}
+}
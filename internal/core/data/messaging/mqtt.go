@@ -0,0 +1,53 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package messaging
+
+import (
+	"encoding/json"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+)
+
+const mqttClientPrefix = "edgex-core-data-"
+
+// MQTT implementation of the event publisher
+type mqttEventPublisher struct {
+	client MQTT.Client
+	topic  string
+}
+
+func newMqttEventPublisher(config PubSubConfiguration) EventPublisher {
+	opts := MQTT.NewClientOptions()
+	opts.AddBroker(config.AddressPort)
+	opts.SetClientID(mqttClientPrefix + uuid.New().String())
+	opts.SetAutoReconnect(true)
+
+	return &mqttEventPublisher{
+		client: MQTT.NewClient(opts),
+		topic:  config.Topic,
+	}
+}
+
+func (mep *mqttEventPublisher) SendEventMessage(e models.Event) error {
+	s, err := json.Marshal(&e)
+	if err != nil {
+		return err
+	}
+
+	if !mep.client.IsConnected() {
+		if token := mep.client.Connect(); token.Wait() && token.Error() != nil {
+			return token.Error()
+		}
+	}
+
+	token := mep.client.Publish(mep.topic, 0, false, s)
+	token.Wait()
+	return token.Error()
+}
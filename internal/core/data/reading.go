@@ -0,0 +1,83 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"github.com/Circutor/edgex/internal/core/data/errors"
+	"github.com/Circutor/edgex/internal/pkg/db"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+func getReadings(offset int, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	readings, err := dbClient.Readings(offset, limit, order)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+func getReadingById(id string) (contract.Reading, error) {
+	reading, err := dbClient.ReadingById(id)
+	if err != nil {
+		if err == db.ErrNotFound {
+			err = errors.NewErrReadingNotFound(id)
+		}
+		return contract.Reading{}, err
+	}
+	return reading, nil
+}
+
+func countReadings() (int, error) {
+	count, err := dbClient.ReadingCount()
+	if err != nil {
+		return -1, err
+	}
+	return count, nil
+}
+
+func getReadingsByDevice(deviceId string, offset int, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	readings, err := dbClient.ReadingsByDevice(deviceId, offset, limit, order)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+func getReadingsByName(name string, offset int, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	readings, err := dbClient.ReadingsByName(name, offset, limit, order)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+func getReadingsByDeviceAndName(deviceId string, name string, start int64, end int64, offset int, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	readings, err := dbClient.ReadingsByDeviceAndName(deviceId, name, start, end, offset, limit, order)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+func getReadingsByCreationTime(start int64, end int64, offset int, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	readings, err := dbClient.ReadingsByCreationTime(start, end, offset, limit, order)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return nil, err
+	}
+
+	return readings, nil
+}
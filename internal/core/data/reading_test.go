@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Circutor/edgex/internal/core/data/errors"
+	dbMock "github.com/Circutor/edgex/internal/core/data/interfaces/mocks"
+	"github.com/Circutor/edgex/internal/pkg/db"
+	"github.com/Circutor/edgex/pkg/models"
+)
+
+func TestGetReadingById(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("ReadingById", "valid").Return(models.Reading{Id: "valid"}, nil)
+	dbClient = myMock
+
+	reading, err := getReadingById("valid")
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if reading.Id != "valid" {
+		t.Errorf("Returned reading %s, expected valid", reading.Id)
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestGetReadingByIdNotFound(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("ReadingById", "abcxyz").Return(models.Reading{}, db.ErrNotFound)
+	dbClient = myMock
+
+	_, err := getReadingById("abcxyz")
+	if _, ok := err.(*errors.ErrReadingNotFound); !ok {
+		t.Errorf("Expected ErrReadingNotFound, got %v", err)
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestGetReadingsByName(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("ReadingsByName", "Pressure", 10, 5, db.NewestFirst).Return(buildReadings(), nil)
+	dbClient = myMock
+
+	readings, err := getReadingsByName("Pressure", 10, 5, db.NewestFirst)
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if len(readings) != len(buildReadings()) {
+		t.Errorf("Returned %d readings, expected %d", len(readings), len(buildReadings()))
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestGetReadingsByCreationTimeDBThrowsError(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("ReadingsByCreationTime", int64(0), int64(100), 0, 10, db.OldestFirst).Return(nil, fmt.Errorf("some error"))
+	dbClient = myMock
+
+	readings, err := getReadingsByCreationTime(0, 100, 0, 10, db.OldestFirst)
+	if err == nil {
+		t.Errorf("Expected error getting readings")
+	}
+
+	if readings != nil {
+		t.Errorf("Expected no readings")
+	}
+
+	myMock.AssertExpectations(t)
+}
@@ -0,0 +1,155 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/db"
+)
+
+// Statistics of the last run of the retention policy
+type RetentionStats struct {
+	LastRun        int64 // When the policy was last enforced
+	Duration       int64 // Milliseconds taken by the last run
+	RemovedByAge   int
+	RemovedByCount int
+	RemovedBySize  int
+	EventCount     int   // Events left after the last run
+	DatabaseSize   int64 // Bytes in use after the last run
+	Error          string
+}
+
+var retentionStats RetentionStats
+var retentionMutex sync.RWMutex
+var retentionStop chan struct{}
+var retentionWait sync.WaitGroup
+
+// Start the worker that periodically enforces the retention policy
+func startRetention() {
+	interval := Configuration.Retention.Interval
+	if interval <= 0 {
+		LoggingClient.Info("Retention policy disabled")
+		return
+	}
+
+	retentionStop = make(chan struct{})
+	retentionWait.Add(1)
+	go func(stop chan struct{}) {
+		defer retentionWait.Done()
+		ticker := time.NewTicker(time.Millisecond * time.Duration(interval))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				runRetention()
+			case <-stop:
+				return
+			}
+		}
+	}(retentionStop)
+}
+
+// Stop the retention worker, waiting for the current run to finish
+func stopRetention() {
+	if retentionStop != nil {
+		close(retentionStop)
+		retentionWait.Wait()
+		retentionStop = nil
+	}
+}
+
+// Get the statistics of the last run of the retention policy
+func lastRetentionStats() RetentionStats {
+	retentionMutex.RLock()
+	defer retentionMutex.RUnlock()
+	return retentionStats
+}
+
+// Enforce the retention policy once and record its statistics
+func runRetention() {
+	start := time.Now()
+	stats, err := purgeEvents(Configuration.Retention)
+	stats.LastRun = db.MakeTimestamp()
+	stats.Duration = time.Since(start).Nanoseconds() / int64(time.Millisecond)
+
+	if err != nil {
+		stats.Error = err.Error()
+		LoggingClient.Error("Error enforcing the retention policy: " + err.Error())
+	}
+
+	removed := stats.RemovedByAge + stats.RemovedByCount + stats.RemovedBySize
+	if removed > 0 {
+		LoggingClient.Info(fmt.Sprintf("Retention policy removed %d events (age: %d, count: %d, size: %d), %d events left",
+			removed, stats.RemovedByAge, stats.RemovedByCount, stats.RemovedBySize, stats.EventCount))
+	} else {
+		LoggingClient.Debug("Retention policy removed no events")
+	}
+
+	retentionMutex.Lock()
+	retentionStats = stats
+	retentionMutex.Unlock()
+}
+
+// Purge the events exceeding the limits of the policy
+// The age limit is applied first, then the number of events and last the database size
+func purgeEvents(policy RetentionInfo) (RetentionStats, error) {
+	stats := RetentionStats{}
+	var err error
+
+	if policy.MaxAge > 0 {
+		if policy.OnlyPushed {
+			stats.RemovedByAge, err = dbClient.DeletePushedEventsOlderThan(policy.MaxAge)
+		} else {
+			stats.RemovedByAge, err = dbClient.DeleteEventsOlderThan(policy.MaxAge)
+		}
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	stats.EventCount, err = dbClient.EventCount()
+	if err != nil {
+		return stats, err
+	}
+
+	if policy.MaxEvents > 0 && stats.EventCount > policy.MaxEvents {
+		stats.RemovedByCount, err = dbClient.DeleteOldestEvents(stats.EventCount-policy.MaxEvents, policy.OnlyPushed)
+		stats.EventCount -= stats.RemovedByCount
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	stats.DatabaseSize, err = dbClient.DatabaseSize()
+	if err != nil {
+		return stats, err
+	}
+
+	if policy.MaxDbSize > 0 && stats.DatabaseSize > policy.MaxDbSize && stats.EventCount > 0 {
+		// Remove the share of the events that exceeds the limit, any error in
+		// the estimation gets corrected in the next run
+		excess := float64(stats.DatabaseSize-policy.MaxDbSize) / float64(stats.DatabaseSize)
+		count := int(math.Ceil(float64(stats.EventCount) * excess))
+		stats.RemovedBySize, err = dbClient.DeleteOldestEvents(count, policy.OnlyPushed)
+		stats.EventCount -= stats.RemovedBySize
+		if err != nil {
+			return stats, err
+		}
+
+		stats.DatabaseSize, err = dbClient.DatabaseSize()
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
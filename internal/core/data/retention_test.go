@@ -0,0 +1,108 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"fmt"
+	"testing"
+
+	dbMock "github.com/Circutor/edgex/internal/core/data/interfaces/mocks"
+)
+
+func TestPurgeEventsDisabled(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("EventCount").Return(10, nil)
+	myMock.On("DatabaseSize").Return(int64(4096), nil)
+	dbClient = myMock
+
+	stats, err := purgeEvents(RetentionInfo{})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if stats.EventCount != 10 || stats.DatabaseSize != 4096 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestPurgeEventsByAge(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("DeleteEventsOlderThan", int64(1000)).Return(3, nil)
+	myMock.On("EventCount").Return(7, nil)
+	myMock.On("DatabaseSize").Return(int64(4096), nil)
+	dbClient = myMock
+
+	stats, err := purgeEvents(RetentionInfo{MaxAge: 1000})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if stats.RemovedByAge != 3 {
+		t.Errorf("expected 3 events removed by age, removed %d", stats.RemovedByAge)
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestPurgeEventsOnlyPushed(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("DeletePushedEventsOlderThan", int64(1000)).Return(2, nil)
+	myMock.On("EventCount").Return(12, nil)
+	myMock.On("DeleteOldestEvents", 2, true).Return(2, nil)
+	myMock.On("DatabaseSize").Return(int64(4096), nil)
+	dbClient = myMock
+
+	stats, err := purgeEvents(RetentionInfo{MaxAge: 1000, MaxEvents: 10, OnlyPushed: true})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if stats.RemovedByAge != 2 || stats.RemovedByCount != 2 || stats.EventCount != 10 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestPurgeEventsBySize(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("EventCount").Return(100, nil)
+	myMock.On("DatabaseSize").Return(int64(2000), nil).Once()
+	myMock.On("DeleteOldestEvents", 25, false).Return(25, nil)
+	myMock.On("DatabaseSize").Return(int64(1500), nil).Once()
+	dbClient = myMock
+
+	stats, err := purgeEvents(RetentionInfo{MaxDbSize: 1500})
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	if stats.RemovedBySize != 25 || stats.EventCount != 75 || stats.DatabaseSize != 1500 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	myMock.AssertExpectations(t)
+}
+
+func TestPurgeEventsError(t *testing.T) {
+	reset()
+	myMock := &dbMock.DBClient{}
+	myMock.On("DeleteEventsOlderThan", int64(1000)).Return(0, fmt.Errorf("some error"))
+	dbClient = myMock
+
+	_, err := purgeEvents(RetentionInfo{MaxAge: 1000})
+	if err == nil {
+		t.Errorf("Should throw error")
+	}
+}
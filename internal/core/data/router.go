@@ -14,8 +14,8 @@
 package data
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -26,7 +26,9 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/Circutor/edgex/internal/core/data/errors"
+	"github.com/Circutor/edgex/internal/pkg/bus"
 	"github.com/Circutor/edgex/internal/pkg/correlation"
+	"github.com/Circutor/edgex/internal/pkg/db"
 	"github.com/Circutor/edgex/internal/pkg/telemetry"
 )
 
@@ -47,6 +49,7 @@ func LoadRestRoutes() *mux.Router {
 	// Events
 	r.HandleFunc(clients.ApiEventRoute, eventHandler).Methods(http.MethodGet, http.MethodPut, http.MethodPost)
 	e := r.PathPrefix(clients.ApiEventRoute).Subrouter()
+	e.HandleFunc("/batch", eventBatchHandler).Methods(http.MethodPost)
 	e.HandleFunc("/scrub", scrubHandler).Methods(http.MethodDelete)
 	e.HandleFunc("/scruball", scrubAllHandler).Methods(http.MethodDelete)
 	e.HandleFunc("/count", eventCountHandler).Methods(http.MethodGet)
@@ -60,6 +63,18 @@ func LoadRestRoutes() *mux.Router {
 	e.HandleFunc("/{start:[0-9]+}/{end:[0-9]+}/{limit:[0-9]+}", eventByCreationTimeHandler).Methods(http.MethodGet)
 	e.HandleFunc("/device/{deviceId}/valuedescriptor/{valueDescriptor}/{limit:[0-9]+}", readingByDeviceFilteredValueDescriptor).Methods(http.MethodGet)
 
+	// Readings
+	r.HandleFunc(clients.ApiReadingRoute, readingHandler).Methods(http.MethodGet)
+	rd := r.PathPrefix(clients.ApiReadingRoute).Subrouter()
+	rd.HandleFunc("/count", readingCountHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/aggregate", readingAggregateHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/{id}", getReadingByIdHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/device/{deviceId}/{limit:[0-9]+}", readingsByDeviceHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/name/{name}/{limit:[0-9]+}", readingsByNameHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/name/{name}/device/{deviceId}/{limit:[0-9]+}", readingsByNameAndDeviceHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/name/{name}/device/{deviceId}/{start:[0-9]+}/{end:[0-9]+}/{limit:[0-9]+}", readingsByNameAndDeviceHandler).Methods(http.MethodGet)
+	rd.HandleFunc("/{start:[0-9]+}/{end:[0-9]+}/{limit:[0-9]+}", readingsByCreationTimeHandler).Methods(http.MethodGet)
+
 	r.Use(correlation.ManageHeader)
 	r.Use(correlation.OnResponseComplete)
 	r.Use(correlation.OnRequestBegin)
@@ -177,22 +192,11 @@ func eventHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		encode(events, w)
+		encode(events, w, r)
 		break
 		// Post a new event
 	case http.MethodPost:
-		contentType := r.Header.Get(clients.ContentType)
-		if contentType == clients.ContentTypeCBOR {
-			errMsg := "CBOR payload is not yet supported"
-			http.Error(w, errMsg, http.StatusNotImplemented)
-			LoggingClient.Error(errMsg)
-
-			return
-		}
-
-		var e models.Event
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&e)
+		e, err := decodeEvent(r)
 
 		// Problem Decoding Event
 		if err != nil {
@@ -221,18 +225,7 @@ func eventHandler(w http.ResponseWriter, r *http.Request) {
 		break
 		// Do not update the readings
 	case http.MethodPut:
-		contentType := r.Header.Get(clients.ContentType)
-		if contentType == clients.ContentTypeCBOR {
-			errMsg := "CBOR payload is not yet supported"
-			http.Error(w, errMsg, http.StatusNotImplemented)
-			LoggingClient.Error(errMsg)
-
-			return
-		}
-
-		var from models.Event
-		dec := json.NewDecoder(r.Body)
-		err := dec.Decode(&from)
+		from, err := decodeEvent(r)
 
 		// Problem decoding event
 		if err != nil {
@@ -260,6 +253,47 @@ func eventHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+/*
+POST
+Add a batch of events
+The body is an array of events, encoded as JSON or CBOR
+Return the status of each event in the same order: its ID when it is added or the error otherwise
+413 - number of events exceeds limit
+/api/v1/event/batch
+*/
+func eventBatchHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ctx := r.Context()
+
+	var events []models.Event
+	err := decodeBody(r, &events)
+
+	// Problem decoding the events
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Error decoding the events: " + err.Error())
+		return
+	}
+
+	err = checkMaxLimit(len(events))
+	if err != nil {
+		http.Error(w, maxExceededString, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	LoggingClient.Debug(fmt.Sprintf("Posting %d events", len(events)))
+
+	statuses, err := addNewEvents(events, ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
+	encode(statuses, w, r)
+}
+
 // Undocumented feature to remove all readings and events from the database
 // This should primarily be used for debugging purposes
 func scrubAllHandler(w http.ResponseWriter, r *http.Request) {
@@ -274,12 +308,13 @@ func scrubAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encode(true, w)
+	encode(true, w, r)
 }
 
 // Get unpushed events
 // Returns the events that are still unpushed to export for all devices sorted by creation date and limited by 'limit'
 // {limit} - the limit of events
+// registration - optional query parameter, only the events not pushed yet by that registration
 // api/v1/event/unpushed/{limit}
 func getUnpushedEventsHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
@@ -301,14 +336,14 @@ func getUnpushedEventsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eventList, err := getUnspushedEventsLimit(limitNum)
+	eventList, err := getUnspushedEventsLimit(limitNum, r.URL.Query().Get(registrationParam))
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	encode(eventList, w)
+	encode(eventList, w, r)
 }
 
 //GET
@@ -338,13 +373,14 @@ func getEventByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encode(e, w)
+	encode(e, w, r)
 }
 
 // Get event by device id
 // Returns the events for the given device sorted by creation date and limited by 'limit'
 // {deviceId} - the device that the events are for
 // {limit} - the limit of events
+// ?order=asc|desc - oldest (default) or newest events first
 // api/v1/event/device/{deviceId}/{limit}
 func getEventByDeviceHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
@@ -369,6 +405,13 @@ func getEventByDeviceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	order, err := sortOrder(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
 	// Check device
 	if err := checkDevice(deviceId, ctx); err != nil {
 		LoggingClient.Error(fmt.Sprintf("error checking device %s %v", deviceId, err))
@@ -390,14 +433,14 @@ func getEventByDeviceHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		eventList, err := getEventsByDeviceIdLimit(limitNum, deviceId)
+		eventList, err := getEventsByDeviceIdLimit(limitNum, deviceId, order)
 
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		encode(eventList, w)
+		encode(eventList, w, r)
 	}
 }
 
@@ -405,6 +448,7 @@ func getEventByDeviceHandler(w http.ResponseWriter, r *http.Request) {
 DELETE, PUT
 Handle events specified by an ID
 /api/v1/event/id/{id}
+PUT accepts the registration query parameter to record which registration pushed the event
 404 - ID not found
 */
 func eventIdHandler(w http.ResponseWriter, r *http.Request) {
@@ -417,18 +461,9 @@ func eventIdHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	// Set the 'pushed' timestamp for the event to the current time - event is going to another (not EdgeX) service
 	case http.MethodPut:
-		contentType := r.Header.Get(clients.ContentType)
-		if contentType == clients.ContentTypeCBOR {
-			errMsg := "CBOR payload is not yet supported"
-			http.Error(w, errMsg, http.StatusNotImplemented)
-			LoggingClient.Error(errMsg, "eventId", id)
-
-			return
-		}
-
 		LoggingClient.Info("Updating event: " + id)
 
-		err := updateEventPushDate(id, ctx)
+		err := updateEventPushDate(id, r.URL.Query().Get(registrationParam), ctx)
 		if err != nil {
 			switch x := err.(type) {
 			case *errors.ErrEventNotFound:
@@ -513,7 +548,7 @@ func deleteByDeviceIdHandler(w http.ResponseWriter, r *http.Request) {
 
 // Get events by creation time
 // {start} - start time, {end} - end time, {limit} - max number of results
-// Sort the events by creation date
+// Sort the events by creation date, ?order=asc|desc for oldest (default) or newest first
 // 413 - number of results exceeds limit
 // 503 - service unavailable
 // api/v1/event/{start}/{end}/{limit}
@@ -545,6 +580,13 @@ func eventByCreationTimeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	order, err := sortOrder(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		err := checkMaxLimit(limit)
@@ -553,14 +595,14 @@ func eventByCreationTimeHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		eventList, err := getEventsByCreationTime(limit, start, end)
+		eventList, err := getEventsByCreationTime(limit, start, end, order)
 
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		encode(eventList, w)
+		encode(eventList, w, r)
 	}
 }
 
@@ -626,7 +668,7 @@ func readingByDeviceFilteredValueDescriptor(w http.ResponseWriter, r *http.Reque
 			return
 		}
 
-		encode(readings, w)
+		encode(readings, w, r)
 	}
 }
 
@@ -650,6 +692,374 @@ func scrubHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+/*
+Return the readings in Core Data up to the max limit defined in config
+?offset={offset} skips the first readings, ?order=asc|desc sorts them by creation date
+/api/v1/reading
+*/
+func readingHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	offset, order, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
+	readings, err := getReadings(offset, Configuration.Service.ReadMaxLimit, order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode(readings, w, r)
+}
+
+/*
+Return number of readings in Core Data
+/api/v1/reading/count
+*/
+func readingCountHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	count, err := countReadings()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(strconv.Itoa(count)))
+	if err != nil {
+		LoggingClient.Error(err.Error())
+	}
+}
+
+// Aggregate the numeric readings of a device by their origin time
+// ?device={deviceId}&name={name}&start={start}&end={end}&bucket={bucket}&fn={fn}
+// {start}, {end} - optional interval of the readings' origin time
+// {bucket} - duration of the buckets (e.g. 15m, 1h, 1d)
+// {fn} - optional comma separated list of avg, min, max, sum, count and last (all by default)
+// api/v1/reading/aggregate
+func readingAggregateHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	query := r.URL.Query()
+	ctx := r.Context()
+
+	deviceId := query.Get("device")
+	name := query.Get("name")
+	if deviceId == "" || name == "" {
+		http.Error(w, "device and name are required", http.StatusBadRequest)
+		LoggingClient.Error("Missing device or name to aggregate the readings")
+		return
+	}
+
+	var err error
+	var start, end int64 = 0, math.MaxInt64
+	if value := query.Get("start"); value != "" {
+		start, err = strconv.ParseInt(value, 10, 64)
+		// Problems converting start time
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			LoggingClient.Error("Problem converting start time: " + err.Error())
+			return
+		}
+	}
+	if value := query.Get("end"); value != "" {
+		end, err = strconv.ParseInt(value, 10, 64)
+		// Problems converting end time
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			LoggingClient.Error("Problem converting end time: " + err.Error())
+			return
+		}
+	}
+
+	bucket, err := parseBucket(query.Get("bucket"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
+	fns, err := parseAggregateFuncs(query.Get("fn"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error(err.Error())
+		return
+	}
+
+	// Check device
+	if err := checkDevice(deviceId, ctx); err != nil {
+		LoggingClient.Error(fmt.Sprintf("error checking device %s %v", deviceId, err))
+		switch err := err.(type) {
+		case *types.ErrServiceClient:
+			http.Error(w, err.Error(), err.StatusCode)
+		default: //return an error on everything else.
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	aggregates, err := aggregateReadings(deviceId, name, start, end, bucket, fns)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode(aggregates, w, r)
+}
+
+//GET
+//Return the reading specified by the reading ID
+///api/v1/reading/{id}
+//id - ID of the reading to return
+func getReadingByIdHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	reading, err := getReadingById(id)
+	if err != nil {
+		switch x := err.(type) {
+		case *errors.ErrReadingNotFound:
+			http.Error(w, x.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, x.Error(), http.StatusInternalServerError)
+		}
+
+		LoggingClient.Error(err.Error())
+		return
+	}
+
+	encode(reading, w, r)
+}
+
+// Get the readings of a device
+// {deviceId} - the device that the readings are for
+// {limit} - the limit of readings
+// ?offset={offset} skips the first readings, ?order=asc|desc sorts them by creation date
+// 413 - number exceeds limit
+// api/v1/reading/device/{deviceId}/{limit}
+func readingsByDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	ctx := r.Context()
+
+	deviceId, err := url.QueryUnescape(vars["deviceId"])
+	// Problems unescaping URL
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem unescaping device ID: " + err.Error())
+		return
+	}
+
+	limit, offset, order, ok := readingListParams(w, r)
+	if !ok {
+		return
+	}
+
+	// Check device
+	if err := checkDevice(deviceId, ctx); err != nil {
+		LoggingClient.Error(fmt.Sprintf("error checking device %s %v", deviceId, err))
+		switch err := err.(type) {
+		case *types.ErrServiceClient:
+			http.Error(w, err.Error(), err.StatusCode)
+		default: //return an error on everything else.
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	readings, err := getReadingsByDevice(deviceId, offset, limit, order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode(readings, w, r)
+}
+
+// Get the readings with the given name (value descriptor)
+// {name} - the name of the readings
+// {limit} - the limit of readings
+// ?offset={offset} skips the first readings, ?order=asc|desc sorts them by creation date
+// 413 - number exceeds limit
+// api/v1/reading/name/{name}/{limit}
+func readingsByNameHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+
+	name, err := url.QueryUnescape(vars["name"])
+	// Problems unescaping URL
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem unescaping reading name: " + err.Error())
+		return
+	}
+
+	limit, offset, order, ok := readingListParams(w, r)
+	if !ok {
+		return
+	}
+
+	readings, err := getReadingsByName(name, offset, limit, order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode(readings, w, r)
+}
+
+// Get the readings of a device with the given name (value descriptor)
+// optionally bounded by their creation time
+// {name} - the name of the readings
+// {deviceId} - the device that the readings are for
+// {start} - start time, {end} - end time, {limit} - the limit of readings
+// ?offset={offset} skips the first readings, ?order=asc|desc sorts them by creation date
+// 413 - number exceeds limit
+// api/v1/reading/name/{name}/device/{deviceId}/{limit}
+// api/v1/reading/name/{name}/device/{deviceId}/{start}/{end}/{limit}
+func readingsByNameAndDeviceHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	ctx := r.Context()
+
+	name, err := url.QueryUnescape(vars["name"])
+	// Problems unescaping URL
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem unescaping reading name: " + err.Error())
+		return
+	}
+
+	deviceId, err := url.QueryUnescape(vars["deviceId"])
+	// Problems unescaping URL
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem unescaping device ID: " + err.Error())
+		return
+	}
+
+	var start, end int64 = 0, math.MaxInt64
+	if _, ok := vars["start"]; ok {
+		start, err = strconv.ParseInt(vars["start"], 10, 64)
+		// Problems converting start time
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			LoggingClient.Error("Problem converting start time: " + err.Error())
+			return
+		}
+
+		end, err = strconv.ParseInt(vars["end"], 10, 64)
+		// Problems converting end time
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			LoggingClient.Error("Problem converting end time: " + err.Error())
+			return
+		}
+	}
+
+	limit, offset, order, ok := readingListParams(w, r)
+	if !ok {
+		return
+	}
+
+	// Check device
+	if err := checkDevice(deviceId, ctx); err != nil {
+		LoggingClient.Error(fmt.Sprintf("error checking device %s %v", deviceId, err))
+		switch err := err.(type) {
+		case *types.ErrServiceClient:
+			http.Error(w, err.Error(), err.StatusCode)
+		default: //return an error on everything else.
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	readings, err := getReadingsByDeviceAndName(deviceId, name, start, end, offset, limit, order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode(readings, w, r)
+}
+
+// Get readings by creation time
+// {start} - start time, {end} - end time, {limit} - max number of results
+// ?offset={offset} skips the first readings, ?order=asc|desc sorts them by creation date
+// 413 - number of results exceeds limit
+// api/v1/reading/{start}/{end}/{limit}
+func readingsByCreationTimeHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	start, err := strconv.ParseInt(vars["start"], 10, 64)
+	// Problems converting start time
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem converting start time: " + err.Error())
+		return
+	}
+
+	end, err := strconv.ParseInt(vars["end"], 10, 64)
+	// Problems converting end time
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem converting end time: " + err.Error())
+		return
+	}
+
+	limit, offset, order, ok := readingListParams(w, r)
+	if !ok {
+		return
+	}
+
+	readings, err := getReadingsByCreationTime(start, end, offset, limit, order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	encode(readings, w, r)
+}
+
+// Parse the {limit} path variable and the paging query parameters of the reading lists
+// The error response is written when they are not valid
+func readingListParams(w http.ResponseWriter, r *http.Request) (int, int, db.SortOrder, bool) {
+	limit, err := strconv.Atoi(mux.Vars(r)["limit"])
+	// Problem converting the limit
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error("Problem converting limit to integer: " + err.Error())
+		return 0, 0, db.OldestFirst, false
+	}
+
+	err = checkMaxLimit(limit)
+	if err != nil {
+		http.Error(w, maxExceededString, http.StatusRequestEntityTooLarge)
+		return 0, 0, db.OldestFirst, false
+	}
+
+	offset, order, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		LoggingClient.Error(err.Error())
+		return 0, 0, db.OldestFirst, false
+	}
+
+	return limit, offset, order, true
+}
+
 // Test if the service is working
 func pingHandler(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "text/plain")
@@ -657,13 +1067,24 @@ func pingHandler(w http.ResponseWriter, _ *http.Request) {
 }
 
 func configHandler(w http.ResponseWriter, r *http.Request) {
-	encode(Configuration, w)
+	encode(Configuration, w, r)
 }
 
-func metricsHandler(w http.ResponseWriter, _ *http.Request) {
-	s := telemetry.NewSystemUsage()
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	s := struct {
+		telemetry.SystemUsage
+		Retention RetentionStats
+		Bus       *bus.Stats `json:",omitempty"` // Only with the in-process bus
+	}{
+		SystemUsage: telemetry.NewSystemUsage(),
+		Retention:   lastRetentionStats(),
+	}
+	if Configuration.MessageQueue.Type == bus.Channel {
+		stats := bus.GetStats()
+		s.Bus = &stats
+	}
 
-	encode(s, w)
+	encode(s, w, r)
 
 	return
 }
@@ -15,15 +15,37 @@ package data
 
 import (
 	"encoding/json"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Circutor/edgex/internal/pkg/db"
+	"github.com/Circutor/edgex/pkg/clients"
+	"github.com/Circutor/edgex/pkg/models"
+	"github.com/ugorji/go/codec"
 )
 
-// Helper function for encoding things for returning from REST calls
-func encode(i interface{}, w http.ResponseWriter) {
-	w.Header().Add("Content-Type", "application/json")
+const (
+	offsetParam       = "offset"
+	orderParam        = "order"
+	orderAscending    = "asc"
+	orderDescending   = "desc"
+	registrationParam = "registration"
+)
 
-	enc := json.NewEncoder(w)
-	err := enc.Encode(i)
+// Helper function for encoding things for returning from REST calls
+// The response is encoded as CBOR when the client accepts it and as JSON otherwise
+func encode(i interface{}, w http.ResponseWriter, r *http.Request) {
+	var err error
+	if acceptsCBOR(r) {
+		w.Header().Add(clients.ContentType, clients.ContentTypeCBOR)
+		err = codec.NewEncoder(w, &codec.CborHandle{}).Encode(i)
+	} else {
+		w.Header().Add(clients.ContentType, clients.ContentTypeJSON)
+		err = json.NewEncoder(w).Encode(i)
+	}
 	// Problems encoding
 	if err != nil {
 		LoggingClient.Error("Error encoding the data: " + err.Error())
@@ -31,3 +53,60 @@ func encode(i interface{}, w http.ResponseWriter) {
 		return
 	}
 }
+
+// Check if the client accepts CBOR responses
+func acceptsCBOR(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get(clients.Accept), ",") {
+		mediaType, _, err := mime.ParseMediaType(accept)
+		if err == nil && mediaType == clients.ContentTypeCBOR {
+			return true
+		}
+	}
+	return false
+}
+
+// Decode the event in the request body as CBOR or JSON depending on its content type
+func decodeEvent(r *http.Request) (models.Event, error) {
+	var e models.Event
+	err := decodeBody(r, &e)
+	return e, err
+}
+
+// Decode the request body as CBOR or JSON depending on its content type
+func decodeBody(r *http.Request, v interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(clients.ContentType))
+	if mediaType == clients.ContentTypeCBOR {
+		return codec.NewDecoder(r.Body, &codec.CborHandle{}).Decode(v)
+	}
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// Read the optional sort order query parameter of the time ordered queries
+// "asc" (default) returns the oldest events first and "desc" the newest ones
+func sortOrder(r *http.Request) (db.SortOrder, error) {
+	switch r.URL.Query().Get(orderParam) {
+	case "", orderAscending:
+		return db.OldestFirst, nil
+	case orderDescending:
+		return db.NewestFirst, nil
+	default:
+		return db.OldestFirst, fmt.Errorf("invalid sort order '%s', expected %s or %s",
+			r.URL.Query().Get(orderParam), orderAscending, orderDescending)
+	}
+}
+
+// Read the optional paging query parameters of the list queries
+// "offset" skips the first results and "order" sorts them
+func pageParams(r *http.Request) (int, db.SortOrder, error) {
+	offset := 0
+	if value := r.URL.Query().Get(offsetParam); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, db.OldestFirst, fmt.Errorf("invalid offset '%s'", value)
+		}
+	}
+
+	order, err := sortOrder(r)
+	return offset, order, err
+}
@@ -0,0 +1,90 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package data
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Circutor/edgex/pkg/clients"
+	"github.com/Circutor/edgex/pkg/models"
+	"github.com/ugorji/go/codec"
+)
+
+func TestDecodeEventCBOR(t *testing.T) {
+	e := models.Event{
+		Device: "device",
+		Origin: 1000,
+		Readings: []models.Reading{
+			{Name: "temperature", Value: "21.5"},
+			{Name: "image", BinaryValue: []byte{0x00, 0x01, 0xff}},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPost, clients.ApiEventRoute, bytes.NewReader(e.CBOR()))
+	req.Header.Set(clients.ContentType, clients.ContentTypeCBOR)
+
+	decoded, err := decodeEvent(req)
+	if err != nil {
+		t.Fatalf("Error decoding the event: %v", err)
+	}
+
+	if decoded.Device != e.Device || decoded.Origin != e.Origin || len(decoded.Readings) != 2 {
+		t.Fatalf("Unexpected event %v", decoded)
+	}
+	if decoded.Readings[0].Value != "21.5" || !bytes.Equal(decoded.Readings[1].BinaryValue, e.Readings[1].BinaryValue) {
+		t.Errorf("Unexpected readings %v", decoded.Readings)
+	}
+}
+
+func TestDecodeEventJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, clients.ApiEventRoute, bytes.NewReader([]byte(`{"device":"device"}`)))
+	req.Header.Set(clients.ContentType, clients.ContentTypeJSON)
+
+	decoded, err := decodeEvent(req)
+	if err != nil {
+		t.Fatalf("Error decoding the event: %v", err)
+	}
+	if decoded.Device != "device" {
+		t.Errorf("Unexpected event %v", decoded)
+	}
+}
+
+func TestEncodeCBOR(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, clients.ApiEventRoute, nil)
+	req.Header.Set(clients.Accept, "application/json;q=0.5, application/cbor")
+	w := httptest.NewRecorder()
+
+	encode([]models.Event{{Device: "device"}}, w, req)
+
+	if w.Header().Get(clients.ContentType) != clients.ContentTypeCBOR {
+		t.Fatalf("Unexpected content type %s", w.Header().Get(clients.ContentType))
+	}
+
+	var events []models.Event
+	err := codec.NewDecoderBytes(w.Body.Bytes(), &codec.CborHandle{}).Decode(&events)
+	if err != nil {
+		t.Fatalf("Error decoding the response: %v", err)
+	}
+	if len(events) != 1 || events[0].Device != "device" {
+		t.Errorf("Unexpected events %v", events)
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, clients.ApiEventRoute, nil)
+	w := httptest.NewRecorder()
+
+	encode([]models.Event{{Device: "device"}}, w, req)
+
+	if w.Header().Get(clients.ContentType) != clients.ContentTypeJSON {
+		t.Errorf("Unexpected content type %s", w.Header().Get(clients.ContentType))
+	}
+}
@@ -64,6 +64,7 @@ func getRegList(w http.ResponseWriter, r *http.Request) {
 	case typeAlgorithms:
 		list = append(list, models.EncNone)
 		list = append(list, models.EncAes)
+		list = append(list, models.EncAesGcm)
 	case typeCompressions:
 		list = append(list, models.CompNone)
 		list = append(list, models.CompGzip)
@@ -74,8 +75,10 @@ func getRegList(w http.ResponseWriter, r *http.Request) {
 		list = append(list, models.FormatIoTCoreJSON)
 		list = append(list, models.FormatAzureJSON)
 		list = append(list, models.FormatAWSJSON)
+		list = append(list, models.FormatCSV)
 		list = append(list, models.FormatThingsBoardJSON)
 		list = append(list, models.FormatNOOP)
+		list = append(list, models.FormatTemplate)
 	case typeDestinations:
 		list = append(list, models.DestMQTT)
 		list = append(list, models.DestIotCoreMQTT)
@@ -360,6 +363,21 @@ func updateReg(w http.ResponseWriter, r *http.Request) {
 	if fromReg.Filter.ValueDescriptorIDs != nil {
 		toReg.Filter.ValueDescriptorIDs = fromReg.Filter.ValueDescriptorIDs
 	}
+	if fromReg.Filter.DevicePattern != "" {
+		toReg.Filter.DevicePattern = fromReg.Filter.DevicePattern
+	}
+	if fromReg.Filter.ReadingPattern != "" {
+		toReg.Filter.ReadingPattern = fromReg.Filter.ReadingPattern
+	}
+	if fromReg.Filter.DeviceLabels != nil {
+		toReg.Filter.DeviceLabels = fromReg.Filter.DeviceLabels
+	}
+	if fromReg.Filter.Values != nil {
+		toReg.Filter.Values = fromReg.Filter.Values
+	}
+	if fromReg.Filter.Deadbands != nil {
+		toReg.Filter.Deadbands = fromReg.Filter.Deadbands
+	}
 	if fromReg.Encryption.Algo != "" {
 		toReg.Encryption = fromReg.Encryption
 	}
@@ -369,6 +387,9 @@ func updateReg(w http.ResponseWriter, r *http.Request) {
 	if fromReg.Destination != "" {
 		toReg.Destination = fromReg.Destination
 	}
+	if fromReg.Template != "" {
+		toReg.Template = fromReg.Template
+	}
 
 	// In order to know if 'enable' parameter have been sent or not, we unmarshal again
 	// the registration in a map[string] and then check if the parameter is present or not
@@ -377,6 +398,18 @@ func updateReg(w http.ResponseWriter, r *http.Request) {
 	if objmap["enable"] != nil {
 		toReg.Enable = fromReg.Enable
 	}
+	if objmap["csv"] != nil {
+		toReg.CSV = fromReg.CSV
+	}
+	if objmap["aggregation"] != nil {
+		toReg.Aggregation = fromReg.Aggregation
+	}
+	if objmap["batch"] != nil {
+		toReg.Batch = fromReg.Batch
+	}
+	if fromReg.DexmaParameters != nil {
+		toReg.DexmaParameters = fromReg.DexmaParameters
+	}
 
 	if toReg.Format == "DEXMA_JSON" && toReg.Destination == "DEXMA_TOPIC" {
 		if toReg.Name == "" {
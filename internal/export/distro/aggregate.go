@@ -0,0 +1,169 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	contract "github.com/Circutor/edgex/pkg/models"
+	"github.com/google/uuid"
+)
+
+// Aggregates the readings of each device during a window and emits a single event per device
+// It is only used from the loop of its registration
+type aggregator struct {
+	window  time.Duration
+	value   string
+	devices map[string]*aggregationWindow
+	pending map[string]bool // Events in the windows not delivered yet, replays of them are ignored
+}
+
+// Readings of a device since the window was opened
+type aggregationWindow struct {
+	device   string
+	opened   time.Time
+	origin   int64
+	ids      []string // Events aggregated
+	keys     [][]byte // Queue entries of the events, acknowledged once the window is delivered
+	names    []string // Reading names in order of arrival
+	readings map[string]*readingStats
+}
+
+type readingStats struct {
+	last    contract.Reading
+	numeric int // Numeric values aggregated
+	sum     float64
+	min     float64
+	max     float64
+}
+
+func newAggregator(options contract.Aggregation) (*aggregator, error) {
+	window, err := time.ParseDuration(options.Window)
+	if err != nil {
+		return nil, err
+	}
+	value := options.Value
+	if value == "" {
+		value = contract.AggregateLast
+	}
+	return &aggregator{
+		window:  window,
+		value:   value,
+		devices: make(map[string]*aggregationWindow),
+		pending: make(map[string]bool),
+	}, nil
+}
+
+// Add the readings of an event to the window of its device, the queue key is nil if it was not queued
+// Return false if the event is already in an open window
+func (a *aggregator) add(id string, key []byte, event *contract.Event, now time.Time) bool {
+	if id != "" {
+		if a.pending[id] {
+			return false
+		}
+		a.pending[id] = true
+	}
+
+	w, ok := a.devices[event.Device]
+	if !ok {
+		w = &aggregationWindow{device: event.Device, opened: now, readings: make(map[string]*readingStats)}
+		a.devices[event.Device] = w
+	}
+	if id != "" {
+		w.ids = append(w.ids, id)
+	}
+	if key != nil {
+		w.keys = append(w.keys, key)
+	}
+	if event.Origin > w.origin {
+		w.origin = event.Origin
+	}
+
+	for _, reading := range event.Readings {
+		stats, ok := w.readings[reading.Name]
+		if !ok {
+			stats = &readingStats{}
+			w.readings[reading.Name] = stats
+			w.names = append(w.names, reading.Name)
+		}
+		stats.last = reading
+
+		value, err := strconv.ParseFloat(reading.Value, 64)
+		if err != nil {
+			continue
+		}
+		if stats.numeric == 0 || value < stats.min {
+			stats.min = value
+		}
+		if stats.numeric == 0 || value > stats.max {
+			stats.max = value
+		}
+		stats.sum += value
+		stats.numeric++
+	}
+	return true
+}
+
+// Remove and return the windows open for the whole window duration, all of them if now is zero
+// Their events are pending until they are done
+func (a *aggregator) due(now time.Time) []*aggregationWindow {
+	var windows []*aggregationWindow
+	for device, w := range a.devices {
+		if !now.IsZero() && now.Sub(w.opened) < a.window {
+			continue
+		}
+		windows = append(windows, w)
+		delete(a.devices, device)
+	}
+	return windows
+}
+
+// Stop ignoring the replays of events once their window is delivered, or failed to be
+func (a *aggregator) done(ids []string) {
+	for _, id := range ids {
+		delete(a.pending, id)
+	}
+}
+
+// Synthetic event of a window, the readings have the min, avg and max of their numeric values
+func (w *aggregationWindow) event(value string) *contract.Event {
+	event := &contract.Event{
+		ID:     w.id(),
+		Device: w.device,
+		Origin: w.origin,
+	}
+	for _, name := range w.names {
+		stats := w.readings[name]
+		reading := stats.last
+		if stats.numeric > 0 {
+			avg := formatFloat(stats.sum / float64(stats.numeric))
+			reading.AvgValue = avg
+			reading.MinValue = formatFloat(stats.min)
+			reading.MaxValue = formatFloat(stats.max)
+			if value == contract.AggregateAvg {
+				reading.Value = avg
+			}
+		}
+		event.Readings = append(event.Readings, reading)
+	}
+	return event
+}
+
+// ID of the aggregated event, the same each time the events are aggregated again
+func (w *aggregationWindow) id() string {
+	if len(w.ids) == 0 {
+		return uuid.New().String()
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(strings.Join(w.ids, "\x00"))).String()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
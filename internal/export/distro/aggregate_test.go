@@ -0,0 +1,117 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+func TestAggregator(t *testing.T) {
+	a, err := newAggregator(contract.Aggregation{Window: "15m", Value: contract.AggregateAvg})
+	if err != nil {
+		t.Fatalf("Error creating aggregator: %v", err)
+	}
+
+	start := time.Now()
+	a.add("1", nil, &contract.Event{Device: "meter1", Origin: 1000, Readings: []contract.Reading{
+		{Name: "POWER", Value: "10"}, {Name: "STATE", Value: "on"},
+	}}, start)
+	a.add("2", nil, &contract.Event{Device: "meter1", Origin: 2000, Readings: []contract.Reading{
+		{Name: "POWER", Value: "30"}, {Name: "STATE", Value: "off"},
+	}}, start.Add(time.Minute))
+	a.add("3", nil, &contract.Event{Device: "meter2", Readings: []contract.Reading{{Name: "POWER", Value: "5"}}}, start.Add(10*time.Minute))
+	// Replays of the events aggregated are ignored
+	a.add("2", nil, &contract.Event{Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "1000"}}}, start.Add(time.Minute))
+
+	if windows := a.due(start.Add(14 * time.Minute)); len(windows) != 0 {
+		t.Fatalf("No window should be due")
+	}
+	windows := a.due(start.Add(15 * time.Minute))
+	if len(windows) != 1 {
+		t.Fatalf("The window of meter1 should be due, there are %d", len(windows))
+	}
+	if len(windows[0].ids) != 2 {
+		t.Errorf("The window should have the two events aggregated")
+	}
+
+	event := windows[0].event(a.value)
+	if event.Device != "meter1" || event.Origin != 2000 || event.ID == "" || len(event.Readings) != 2 {
+		t.Fatalf("Invalid aggregated event: %v", event)
+	}
+	if again := windows[0].event(a.value); again.ID != event.ID {
+		t.Errorf("The same events aggregated again should have the same ID: %s, %s", event.ID, again.ID)
+	}
+	power := event.Readings[0]
+	if power.Value != "20" || power.AvgValue != "20" || power.MinValue != "10" || power.MaxValue != "30" {
+		t.Errorf("Invalid aggregated reading: %v", power)
+	}
+	state := event.Readings[1]
+	if state.Value != "off" || state.AvgValue != "" {
+		t.Errorf("Non numeric readings should keep the last value: %v", state)
+	}
+
+	if windows := a.due(time.Time{}); len(windows) != 1 || windows[0].device != "meter2" {
+		t.Errorf("All the windows should be due")
+	}
+	if len(a.pending) != 3 {
+		t.Errorf("The events should be pending until their windows are done")
+	}
+	a.done([]string{"1", "2", "3"})
+	if len(a.pending) != 0 {
+		t.Errorf("No event should be pending")
+	}
+}
+
+func TestAggregatorLastValue(t *testing.T) {
+	a, _ := newAggregator(contract.Aggregation{Window: "1m"})
+	now := time.Now()
+	a.add("", nil, &contract.Event{Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "10"}}}, now)
+	a.add("", nil, &contract.Event{Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "12.5"}}}, now)
+
+	event := a.due(time.Time{})[0].event(a.value)
+	if event.Readings[0].Value != "12.5" || event.Readings[0].AvgValue != "11.25" {
+		t.Errorf("The last value should be kept: %v", event.Readings[0])
+	}
+
+	if _, err := newAggregator(contract.Aggregation{Window: "often"}); err == nil {
+		t.Errorf("Invalid windows should fail")
+	}
+}
+
+func TestRegistrationAggregation(t *testing.T) {
+	dummy := &dummyStruct{}
+	reg := registrationInfo{
+		format:   dummy,
+		sender:   dummy,
+		counters: &eventCounters{},
+	}
+	reg.aggregator, _ = newAggregator(contract.Aggregation{Window: "1h"})
+
+	for i := 0; i < 3; i++ {
+		event := &models.Event{Event: contract.Event{Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "1"}}}}
+		if !reg.processEvent(event) {
+			t.Fatalf("The event should be aggregated")
+		}
+	}
+	if dummy.count != 0 {
+		t.Fatalf("The events should not be sent until the window is due")
+	}
+
+	reg.flushAggregates(time.Now())
+	if dummy.count != 0 {
+		t.Fatalf("The window should not be due yet")
+	}
+	reg.flushAggregates(time.Time{})
+	if dummy.count != 1 {
+		t.Errorf("A single aggregated event should be sent, not %d", dummy.count)
+	}
+}
@@ -0,0 +1,78 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"time"
+
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Latency of the batches without max latency
+const defaultBatchLatency = 10 * time.Second
+
+// Events waiting to be sent in a single payload
+// It is only used from the loop of its registration
+type eventBatch struct {
+	maxEvents int
+	maxBytes  int
+	latency   time.Duration
+
+	events []*contract.Event
+	ids    []string // Events the batch comes from
+	keys   [][]byte // Queue entries of those events, acknowledged once the batch is sent
+	size   int      // Bytes of the events formatted
+	opened time.Time
+}
+
+func newEventBatch(options contract.Batch) (*eventBatch, error) {
+	latency := defaultBatchLatency
+	if options.MaxLatency != "" {
+		var err error
+		if latency, err = time.ParseDuration(options.MaxLatency); err != nil {
+			return nil, err
+		}
+	}
+	return &eventBatch{maxEvents: options.MaxEvents, maxBytes: options.MaxBytes, latency: latency}, nil
+}
+
+func (b *eventBatch) add(event *contract.Event, ids []string, keys [][]byte, size int, now time.Time) {
+	if len(b.events) == 0 {
+		b.opened = now
+	}
+	b.events = append(b.events, event)
+	b.ids = append(b.ids, ids...)
+	b.keys = append(b.keys, keys...)
+	b.size += size
+}
+
+// Return true if an event of that size does not fit in the batch
+func (b *eventBatch) exceeds(size int) bool {
+	return b.maxBytes > 0 && len(b.events) > 0 && b.size+size > b.maxBytes
+}
+
+// Return true if the batch has reached its max events or bytes
+func (b *eventBatch) full() bool {
+	return (b.maxEvents > 0 && len(b.events) >= b.maxEvents) || (b.maxBytes > 0 && b.size >= b.maxBytes)
+}
+
+// Return true if the batch must be sent, when it is full or its first event reaches the latency
+// Any batch with events is due if now is zero
+func (b *eventBatch) due(now time.Time) bool {
+	if len(b.events) == 0 {
+		return false
+	}
+	return now.IsZero() || b.full() || now.Sub(b.opened) >= b.latency
+}
+
+// Return and remove the events of the batch
+func (b *eventBatch) take() ([]*contract.Event, []string, [][]byte) {
+	events, ids, keys := b.events, b.ids, b.keys
+	b.events, b.ids, b.keys, b.size = nil, nil, nil, 0
+	return events, ids, keys
+}
@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Sender keeping the payloads sent
+type recordingSender struct {
+	payloads [][]byte
+}
+
+func (sender *recordingSender) Send(data []byte, event *models.Event) bool {
+	sender.payloads = append(sender.payloads, data)
+	return true
+}
+
+func TestEventBatch(t *testing.T) {
+	b, err := newEventBatch(contract.Batch{MaxEvents: 3, MaxBytes: 100, MaxLatency: "1m"})
+	if err != nil {
+		t.Fatalf("Error creating batch: %v", err)
+	}
+
+	start := time.Now()
+	if b.due(time.Time{}) {
+		t.Errorf("Empty batches should not be due")
+	}
+	b.add(&contract.Event{}, []string{"1"}, nil, 40, start)
+	b.add(&contract.Event{}, []string{"2"}, nil, 40, start.Add(time.Second))
+	if b.full() || b.due(start.Add(59*time.Second)) {
+		t.Errorf("The batch should not be due yet")
+	}
+	if !b.due(start.Add(time.Minute)) {
+		t.Errorf("The batch should be due after the latency")
+	}
+	if !b.exceeds(30) {
+		t.Errorf("The event should not fit in the batch")
+	}
+
+	b.add(&contract.Event{}, []string{"3"}, nil, 10, start)
+	if !b.full() {
+		t.Errorf("The batch should be full with the max events")
+	}
+	events, ids, _ := b.take()
+	if len(events) != 3 || len(ids) != 3 || b.size != 0 || b.due(time.Time{}) {
+		t.Errorf("The events should be taken from the batch")
+	}
+
+	if _, err := newEventBatch(contract.Batch{MaxLatency: "later"}); err == nil {
+		t.Errorf("Invalid latencies should fail")
+	}
+}
+
+func TestRegistrationBatch(t *testing.T) {
+	sender := &recordingSender{}
+	reg := registrationInfo{
+		format:   jsonFormatter{},
+		sender:   sender,
+		counters: &eventCounters{},
+	}
+	reg.batch, _ = newEventBatch(contract.Batch{MaxEvents: 2})
+
+	for i := 0; i < 5; i++ {
+		event := &models.Event{Event: contract.Event{Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "1"}}}}
+		if !reg.processEvent(event) {
+			t.Fatalf("The event should be batched")
+		}
+	}
+	if len(sender.payloads) != 2 {
+		t.Fatalf("Two full batches should be sent, not %d", len(sender.payloads))
+	}
+
+	reg.flushBatch(time.Now())
+	if len(sender.payloads) != 2 {
+		t.Fatalf("The last batch should wait for the latency")
+	}
+	reg.flushBatch(time.Time{})
+	if len(sender.payloads) != 3 {
+		t.Fatalf("The last batch should be sent")
+	}
+
+	var events []contract.Event
+	if err := json.Unmarshal(sender.payloads[0], &events); err != nil || len(events) != 2 {
+		t.Errorf("The batch should be a JSON array of two events: %s", sender.payloads[0])
+	}
+	if reg.counters.sent != 5 {
+		t.Errorf("All the events should be counted as sent, not %d", reg.counters.sent)
+	}
+}
+
+func TestRegistrationBatchFailed(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	sender := &flakySender{}
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "batched", Enable: true}
+	reg.format = jsonFormatter{}
+	reg.sender = sender
+	reg.queue = newEventQueue(reg.registration.Name)
+	reg.batch, _ = newEventBatch(contract.Batch{MaxEvents: 2})
+
+	for _, id := range []string{"1", "2", "3"} {
+		if err := reg.store(newTestEvent(id)); err != nil {
+			t.Fatalf("Error storing event: %v", err)
+		}
+		reg.forward()
+	}
+	if len(reg.batch.events) != 2 || reg.queue.length() != 3 {
+		t.Fatalf("The failed batch should be kept with its events queued, %d queued", reg.queue.length())
+	}
+
+	sender.up = true
+	reg.forward()
+	if len(sender.sent) != 1 || reg.queue.length() != 1 {
+		t.Fatalf("The batch should be sent and its events removed from the queue, %d queued", reg.queue.length())
+	}
+	if !reg.flushBatch(time.Time{}) || reg.queue.length() != 0 {
+		t.Errorf("The last batch should be sent, %d queued", reg.queue.length())
+	}
+}
+
+func TestAggregatedBatch(t *testing.T) {
+	sender := &flakySender{}
+	reg := registrationInfo{
+		format:   jsonFormatter{},
+		sender:   sender,
+		counters: &eventCounters{},
+	}
+	reg.aggregator, _ = newAggregator(contract.Aggregation{Window: "1h"})
+	reg.batch, _ = newEventBatch(contract.Batch{MaxEvents: 10})
+
+	event := &models.Event{Event: contract.Event{ID: "1", Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "1"}}}}
+	reg.processEvent(event)
+	reg.flushAggregates(time.Time{})
+
+	// Replays of the events of a window waiting in the batch are not aggregated again
+	reg.processEvent(event)
+	if len(reg.aggregator.devices) != 0 {
+		t.Fatalf("The event in the batch should not be aggregated again")
+	}
+	if reg.flushBatch(time.Time{}) || !reg.aggregator.pending["1"] {
+		t.Fatalf("The event should be pending while the batch is not sent")
+	}
+
+	sender.up = true
+	if !reg.flushBatch(time.Time{}) || len(sender.sent) != 1 || len(reg.aggregator.pending) != 0 {
+		t.Errorf("The batch should be sent and its events done")
+	}
+}
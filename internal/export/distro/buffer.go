@@ -0,0 +1,136 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+// Overflow policies of the registration buffers
+const (
+	OverflowDropOldest = "drop-oldest"
+	OverflowDropNewest = "drop-newest"
+	OverflowBlock      = "block"
+
+	defaultEventBufferSize = 100
+)
+
+// Counters of the events of a registration
+type RegistrationStats struct {
+	Queued  int    // Events waiting in the buffer
+	Stored  int    // Events waiting in the persistent queue
+	Sent    uint64 // Events sent to the destination
+	Failed  uint64 // Events the destination did not accept
+	Dropped uint64 // Events dropped because the buffer was full
+	Circuit string // State of the circuit breaker: closed, open or half-open
+}
+
+type eventCounters struct {
+	sent    uint64
+	failed  uint64
+	dropped uint64
+}
+
+// Registrations running, by name, to report their counters
+var running = struct {
+	sync.RWMutex
+	byName map[string]*registrationInfo
+}{byName: make(map[string]*registrationInfo)}
+
+func newEventBuffer() chan *models.Event {
+	size := Configuration.EventBuffer.Size
+	if size <= 0 {
+		size = defaultEventBufferSize
+	}
+	return make(chan *models.Event, size)
+}
+
+// Queue an event in the registration buffer applying the overflow policy when it is full
+// The event is dropped if the registration goroutine has terminated
+func (reg *registrationInfo) queueEvent(event *models.Event) {
+	switch Configuration.EventBuffer.Overflow {
+	case OverflowBlock:
+		select {
+		case reg.chEvent <- event:
+		case <-reg.done:
+		}
+
+	case OverflowDropNewest:
+		select {
+		case reg.chEvent <- event:
+		default:
+			atomic.AddUint64(&reg.counters.dropped, 1)
+			LoggingClient.Warn(fmt.Sprintf("Buffer of registration %s full, dropping the newest event", reg.name()))
+		}
+
+	default:
+		for {
+			select {
+			case reg.chEvent <- event:
+				return
+			default:
+			}
+
+			// Make room for the event, the registration may have taken one meanwhile
+			select {
+			case <-reg.chEvent:
+				atomic.AddUint64(&reg.counters.dropped, 1)
+				LoggingClient.Warn(fmt.Sprintf("Buffer of registration %s full, dropping the oldest event", reg.name()))
+			default:
+			}
+		}
+	}
+}
+
+// Count the result of sending an event
+func (reg *registrationInfo) countSent(ok bool) {
+	if ok {
+		atomic.AddUint64(&reg.counters.sent, 1)
+	} else {
+		atomic.AddUint64(&reg.counters.failed, 1)
+	}
+}
+
+func trackRegistration(name string, reg *registrationInfo) {
+	running.Lock()
+	defer running.Unlock()
+	running.byName[name] = reg
+}
+
+func untrackRegistration(name string) {
+	running.Lock()
+	defer running.Unlock()
+	delete(running.byName, name)
+}
+
+// Return the counters of the running registrations by name
+func registrationStats() map[string]RegistrationStats {
+	running.RLock()
+	defer running.RUnlock()
+
+	stats := make(map[string]RegistrationStats, len(running.byName))
+	for name, reg := range running.byName {
+		stored := 0
+		if reg.queue != nil {
+			stored = reg.queue.length()
+		}
+		stats[name] = RegistrationStats{
+			Queued:  len(reg.chEvent),
+			Stored:  stored,
+			Sent:    atomic.LoadUint64(&reg.counters.sent),
+			Failed:  atomic.LoadUint64(&reg.counters.failed),
+			Dropped: atomic.LoadUint64(&reg.counters.dropped),
+			Circuit: reg.breaker.currentState(),
+		}
+	}
+	return stats
+}
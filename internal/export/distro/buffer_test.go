@@ -0,0 +1,111 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+func newTestEvent(id string) *models.Event {
+	e := &models.Event{}
+	e.ID = id
+	return e
+}
+
+func TestQueueEventDropOldest(t *testing.T) {
+	Configuration.EventBuffer = EventBufferInfo{Size: 2, Overflow: OverflowDropOldest}
+	defer func() { Configuration.EventBuffer = EventBufferInfo{} }()
+
+	reg := newRegistrationInfo()
+	for _, id := range []string{"1", "2", "3"} {
+		reg.queueEvent(newTestEvent(id))
+	}
+
+	if reg.counters.dropped != 1 {
+		t.Errorf("There should be 1 event dropped, not %d", reg.counters.dropped)
+	}
+	if e := <-reg.chEvent; e.ID != "2" {
+		t.Errorf("The oldest event should be dropped, got %s", e.ID)
+	}
+	if e := <-reg.chEvent; e.ID != "3" {
+		t.Errorf("The newest event should be queued, got %s", e.ID)
+	}
+}
+
+func TestQueueEventDropNewest(t *testing.T) {
+	Configuration.EventBuffer = EventBufferInfo{Size: 2, Overflow: OverflowDropNewest}
+	defer func() { Configuration.EventBuffer = EventBufferInfo{} }()
+
+	reg := newRegistrationInfo()
+	for _, id := range []string{"1", "2", "3"} {
+		reg.queueEvent(newTestEvent(id))
+	}
+
+	if reg.counters.dropped != 1 {
+		t.Errorf("There should be 1 event dropped, not %d", reg.counters.dropped)
+	}
+	if e := <-reg.chEvent; e.ID != "1" {
+		t.Errorf("The oldest event should be queued, got %s", e.ID)
+	}
+	if e := <-reg.chEvent; e.ID != "2" {
+		t.Errorf("The newest event should be dropped, got %s", e.ID)
+	}
+}
+
+func TestRegistrationStats(t *testing.T) {
+	reg := newRegistrationInfo()
+	reg.queueEvent(newTestEvent("1"))
+	reg.countSent(true)
+	reg.countSent(false)
+
+	trackRegistration("test", reg)
+	defer untrackRegistration("test")
+
+	stats, ok := registrationStats()["test"]
+	if !ok {
+		t.Fatalf("Registration test should be reported")
+	}
+	if stats.Queued != 1 || stats.Sent != 1 || stats.Failed != 1 || stats.Dropped != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if cap(reg.chEvent) != defaultEventBufferSize {
+		t.Errorf("The buffer should have the default size, not %d", cap(reg.chEvent))
+	}
+}
+
+func TestQueueEventBlockTerminated(t *testing.T) {
+	Configuration.EventBuffer = EventBufferInfo{Size: 1, Overflow: OverflowBlock}
+	defer func() { Configuration.EventBuffer = EventBufferInfo{} }()
+
+	reg := newRegistrationInfo()
+	reg.queueEvent(newTestEvent("1"))
+
+	// The goroutine of the registration terminates after an invalid update
+	go registrationLoop(reg)
+	reg.chRegistration <- &contract.Registration{Name: "blocked", Format: "INVALID"}
+	<-reg.done
+
+	queued := make(chan struct{})
+	go func() {
+		reg.queueEvent(newTestEvent("2"))
+		reg.queueEvent(newTestEvent("3"))
+		close(queued)
+	}()
+	select {
+	case <-queued:
+	case <-time.After(time.Second):
+		t.Fatalf("Queuing should not block once the registration goroutine has terminated")
+	}
+	if !reg.deleted() || reg.notify(nil) {
+		t.Errorf("The registration goroutine should be terminated")
+	}
+}
@@ -16,12 +16,17 @@ package distro
 import "github.com/Circutor/edgex/internal/pkg/config"
 
 type ConfigurationStruct struct {
-	Writable       WritableInfo
-	Clients        map[string]config.ClientInfo
-	Logging        config.LoggingInfo
-	MessageQueue   config.MessageQueueInfo
-	AnalyticsQueue config.MessageQueueInfo
-	Service        config.ServiceInfo
+	Writable        WritableInfo
+	Clients         map[string]config.ClientInfo
+	Logging         config.LoggingInfo
+	MessageQueue    config.MessageQueueInfo
+	AnalyticsQueue  config.MessageQueueInfo
+	Service         config.ServiceInfo
+	EventBuffer     EventBufferInfo
+	StoreAndForward StoreAndForwardInfo
+	Retry           RetryInfo
+	IoTCore         IoTCoreInfo
+	Dexma           DexmaInfo
 }
 
 type WritableInfo struct {
@@ -29,6 +34,42 @@ type WritableInfo struct {
 	LogLevel   string
 }
 
+// Buffer of the events waiting to be sent by each registration
+type EventBufferInfo struct {
+	Size     int    // Events in the buffer of each registration
+	Overflow string // Policy when the buffer is full: drop-oldest (default), drop-newest or block
+}
+
+// Persistent queue of the events waiting to be sent by each registration
+type StoreAndForwardInfo struct {
+	Enabled       bool
+	File          string // Bolt file of the queues
+	MaxEvents     int    // Events kept by each registration, 0 for no limit
+	MaxAge        int64  // Milliseconds an event is kept, 0 for no limit
+	RetryInterval int    // Milliseconds between retries while the destination is down
+}
+
+// Retries of the sends and circuit breaker of each registration
+type RetryInfo struct {
+	Attempts         int     // Sends of each event before giving up
+	BaseDelay        int     // Milliseconds before the first retry, doubled on every retry
+	MaxDelay         int     // Maximum milliseconds between retries
+	Jitter           float64 // Fraction of the delay randomly removed, between 0 and 1
+	FailureThreshold int     // Events failed in a row that open the circuit
+	OpenTimeout      int     // Milliseconds the circuit stays open before probing the destination
+}
+
+// Google IoT Core registrations
+type IoTCoreInfo struct {
+	TokenLifetime int // Milliseconds the JWTs are valid, they are refreshed before expiring
+}
+
+// Dexma registrations
+type DexmaInfo struct {
+	ParametersFile string // JSON object mapping reading names to Dexma parameter codes, added to the builtin ones
+	SequenceFile   string // Store of the sequence numbers of each registration and device
+}
+
 type CertificateInfo struct {
 	Cert string
 	Key  string
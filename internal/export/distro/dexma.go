@@ -8,11 +8,37 @@
 package distro
 
 import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/Circutor/edgex/pkg/models"
+	bolt "go.etcd.io/bbolt"
 )
 
+const (
+	defaultDexmaSequenceFile = "distro-dexma.db"
+
+	// Sequence numbers assigned to events not delivered yet are kept this long
+	dexmaAssignedExpiration = 7 * 24 * time.Hour
+)
+
+// Bucket of the sequence numbers assigned to the events, inside the bucket of the registration
+var dexmaAssignedBucket = []byte("\x00assigned")
+
+// Store of the last sequence number sent to each device, one bucket per registration,
+// and of the numbers assigned to the events not delivered yet
+// It is opened with the first Dexma registration
+var dexmaDB struct {
+	sync.Mutex
+	db *bolt.DB
+}
+
 var DexmaParameterTable = []struct {
 	name    string
 	codenum int
@@ -49,20 +75,236 @@ var DexmaParameterTable = []struct {
 }
 
 // newHTTPDexmaSender - create http dexma sender
-func newHTTPDexmaSender(addr models.Addressable) sender {
-	sender := httpSender{
-		url:    addr.Protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + "/" + addr.Topic + "?source_key=" + addr.User + "&dexcell_source_token=" + addr.Password,
-		method: addr.HTTPMethod,
+func newHTTPDexmaSender(addr models.Addressable, content httpContent) sender {
+	url := addr.Protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + "/" + addr.Topic + "?source_key=" + addr.User + "&dexcell_source_token=" + addr.Password
+	return newHTTPSenderWithURL(url, addr, content)
+}
+
+// Return the Dexma parameter codes by reading name, the ones of the table overridden by those of the
+// parameters file of the configuration and then by those of the registration
+func dexmaParameters(custom map[string]int) (map[string]int, error) {
+	parameters := make(map[string]int)
+	for _, p := range DexmaParameterTable {
+		parameters[p.name] = p.codenum
+	}
+
+	if file := Configuration.Dexma.ParametersFile; file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read Dexma parameters file: %v", err)
+		}
+		var fromFile map[string]int
+		if err := json.Unmarshal(data, &fromFile); err != nil {
+			return nil, fmt.Errorf("could not parse Dexma parameters file %s: %v", file, err)
+		}
+		for name, code := range fromFile {
+			parameters[name] = code
+		}
+	}
+
+	for name, code := range custom {
+		parameters[name] = code
+	}
+	return parameters, nil
+}
+
+// Sequence numbers of the devices of a registration, they survive restarts and updates
+// The number assigned to an event is kept until it is delivered, so the event is sent
+// again with the same number and Dexma can detect the duplicates
+type dexmaSequences struct {
+	bucket []byte
+}
+
+func newDexmaSequences(registration string) (dexmaSequences, error) {
+	dexmaDB.Lock()
+	defer dexmaDB.Unlock()
+
+	if dexmaDB.db == nil {
+		file := Configuration.Dexma.SequenceFile
+		if file == "" {
+			file = defaultDexmaSequenceFile
+		}
+		db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: queueOpenTimeout})
+		if err != nil {
+			return dexmaSequences{}, fmt.Errorf("couldn't open the Dexma sequence numbers %s: %v", file, err)
+		}
+		dexmaDB.db = db
+	}
+
+	s := dexmaSequences{bucket: []byte(registration)}
+	if err := dexmaDB.db.Update(s.prune); err != nil {
+		return dexmaSequences{}, fmt.Errorf("couldn't prune the Dexma sequence numbers of %s: %v", registration, err)
+	}
+	return s, nil
+}
+
+func closeDexmaSequences() {
+	dexmaDB.Lock()
+	defer dexmaDB.Unlock()
+
+	if dexmaDB.db != nil {
+		dexmaDB.db.Close()
+		dexmaDB.db = nil
+	}
+}
+
+// Run a transaction on the Dexma store, it fails once the store is closed
+func dexmaTx(writable bool, fn func(tx *bolt.Tx) error) error {
+	dexmaDB.Lock()
+	defer dexmaDB.Unlock()
+
+	if dexmaDB.db == nil {
+		return errors.New("the Dexma sequence numbers are closed")
+	}
+	if writable {
+		return dexmaDB.db.Update(fn)
 	}
-	return sender
+	return dexmaDB.db.View(fn)
 }
 
-func transformDexmaParam(name string) int {
+// Return the sequence numbers of the events, in a single transaction
+// An event formatted before gets the number it was assigned then, the others
+// advance the sequence number of their device
+func (s dexmaSequences) next(events []*models.Event) ([]uint64, error) {
+	sqns := make([]uint64, len(events))
+	err := dexmaTx(true, func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(s.bucket)
+		if err != nil {
+			return err
+		}
+		assigned, err := b.CreateBucketIfNotExists(dexmaAssignedBucket)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixNano()
+		for i, event := range events {
+			key := dexmaEventKey(event)
+			if v := assigned.Get(key); key != nil && v != nil {
+				sqns[i] = binary.BigEndian.Uint64(v)
+				continue
+			}
+			if v := b.Get([]byte(event.Device)); v != nil {
+				sqns[i] = binary.BigEndian.Uint64(v)
+			}
+			sqns[i]++
+			v := make([]byte, 8)
+			binary.BigEndian.PutUint64(v, sqns[i])
+			if err := b.Put([]byte(event.Device), v); err != nil {
+				return err
+			}
+			if key == nil {
+				continue
+			}
+			v = make([]byte, 16)
+			binary.BigEndian.PutUint64(v, sqns[i])
+			binary.BigEndian.PutUint64(v[8:], uint64(now))
+			if err := assigned.Put(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return sqns, err
+}
+
+// Forget the sequence numbers assigned to the events delivered
+func (s dexmaSequences) release(events []*models.Event) error {
+	return dexmaTx(true, func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil {
+			return nil
+		}
+		assigned := b.Bucket(dexmaAssignedBucket)
+		if assigned == nil {
+			return nil
+		}
+		for _, event := range events {
+			if key := dexmaEventKey(event); key != nil {
+				if err := assigned.Delete(key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Forget the sequence numbers assigned to events too long ago, they are not sent again
+func (s dexmaSequences) prune(tx *bolt.Tx) error {
+	b := tx.Bucket(s.bucket)
+	if b == nil {
+		return nil
+	}
+	assigned := b.Bucket(dexmaAssignedBucket)
+	if assigned == nil {
+		return nil
+	}
+	limit := uint64(time.Now().Add(-dexmaAssignedExpiration).UnixNano())
+	var expired [][]byte
+	assigned.ForEach(func(k, v []byte) error {
+		if len(v) < 16 || binary.BigEndian.Uint64(v[8:]) < limit {
+			expired = append(expired, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	for _, k := range expired {
+		if err := assigned.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	for i := 0; i < len(DexmaParameterTable); i++ {
-		if name == DexmaParameterTable[i].name {
-			return DexmaParameterTable[i].codenum
+// Sequence number of the event if it is formatted now
+func (s dexmaSequences) peek(event *models.Event) uint64 {
+	var sqn uint64
+	dexmaTx(false, func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		if b == nil {
+			sqn = 1
+			return nil
+		}
+		if assigned := b.Bucket(dexmaAssignedBucket); assigned != nil && dexmaEventKey(event) != nil {
+			if v := assigned.Get(dexmaEventKey(event)); v != nil {
+				sqn = binary.BigEndian.Uint64(v)
+				return nil
+			}
 		}
+		if v := b.Get([]byte(event.Device)); v != nil {
+			sqn = binary.BigEndian.Uint64(v)
+		}
+		sqn++
+		return nil
+	})
+	return sqn
+}
+
+// Key of the sequence number assigned to an event, nil if it has no ID
+// The parts of an event split by reading share its ID, so the names of the readings are part of it
+func dexmaEventKey(event *models.Event) []byte {
+	if event.ID == "" {
+		return nil
+	}
+	key := []byte(event.ID)
+	for _, reading := range event.Readings {
+		key = append(append(key, 0), reading.Name...)
 	}
-	return 0
+	return key
+}
+
+// Remove the sequence numbers of a registration
+func dropDexmaSequences(registration string) error {
+	dexmaDB.Lock()
+	defer dexmaDB.Unlock()
+
+	if dexmaDB.db == nil {
+		return nil
+	}
+	return dexmaDB.db.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket([]byte(registration))
+		if err == bolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
 }
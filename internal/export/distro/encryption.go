@@ -10,8 +10,12 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/base64"
+	"fmt"
 
 	"github.com/Circutor/edgex/pkg/models"
 )
@@ -62,3 +66,65 @@ func (aesData aesEncryption) Transform(data []byte) []byte {
 
 	return encodedData
 }
+
+// AES-GCM envelope: version, salt, nonce, ciphertext and tag, encoded in base64
+// The key is derived for every message from the registration key and a random salt with HKDF-SHA256
+const (
+	gcmVersion  byte = 1
+	gcmSaltSize      = 16
+	gcmKeySize       = 32
+	gcmInfo          = "edgex export-distro AES_GCM"
+)
+
+type aesGCMEncryption struct {
+	key []byte
+}
+
+func newAESGCMEncryption(encData models.EncryptionDetails) transformer {
+	return aesGCMEncryption{key: []byte(encData.Key)}
+}
+
+func (aesData aesGCMEncryption) Transform(data []byte) []byte {
+	salt := make([]byte, gcmSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		LoggingClient.Error(fmt.Sprintf("Could not generate salt: %s", err.Error()))
+		return nil
+	}
+
+	block, err := aes.NewCipher(hkdf(aesData.key, salt, []byte(gcmInfo), gcmKeySize))
+	if err != nil {
+		return nil
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		LoggingClient.Error(fmt.Sprintf("Could not generate nonce: %s", err.Error()))
+		return nil
+	}
+
+	envelope := append([]byte{gcmVersion}, salt...)
+	envelope = append(envelope, nonce...)
+	envelope = gcm.Seal(envelope, nonce, data, nil)
+	return []byte(base64.StdEncoding.EncodeToString(envelope))
+}
+
+// HKDF with SHA-256 (RFC 5869)
+func hkdf(secret, salt, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	var okm, previous []byte
+	for i := byte(1); len(okm) < length; i++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(previous)
+		expand.Write(info)
+		expand.Write([]byte{i})
+		previous = expand.Sum(nil)
+		okm = append(okm, previous...)
+	}
+	return okm[:length]
+}
@@ -11,6 +11,8 @@ import (
 	"crypto/cipher"
 	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 
 	"testing"
 
@@ -72,3 +74,70 @@ func TestAES(t *testing.T) {
 		t.Fatal("Encoded string ", string(plainString), " is not ", string(decphrd))
 	}
 }
+
+func aesGCMDecrypt(crypt []byte, aesData models.EncryptionDetails) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(string(crypt))
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope) < 1+gcmSaltSize || envelope[0] != gcmVersion {
+		return nil, errors.New("invalid envelope")
+	}
+	salt := envelope[1 : 1+gcmSaltSize]
+
+	block, err := aes.NewCipher(hkdf([]byte(aesData.Key), salt, []byte(gcmInfo), gcmKeySize))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := envelope[1+gcmSaltSize : 1+gcmSaltSize+gcm.NonceSize()]
+	return gcm.Open(nil, nonce, envelope[1+gcmSaltSize+gcm.NonceSize():], nil)
+}
+
+func TestAESGCM(t *testing.T) {
+	aesData := models.EncryptionDetails{
+		Algo: models.EncAesGcm,
+		Key:  key,
+	}
+
+	enc := newAESGCMEncryption(aesData)
+
+	cphrd := enc.Transform([]byte(plainString))
+	decphrd, err := aesGCMDecrypt(cphrd, aesData)
+	if err != nil {
+		t.Fatalf("Error decrypting: %v", err)
+	}
+	if string(plainString) != string(decphrd) {
+		t.Fatal("Encoded string ", string(plainString), " is not ", string(decphrd))
+	}
+
+	if string(cphrd) == string(enc.Transform([]byte(plainString))) {
+		t.Fatal("The same plaintext should not give the same ciphertext")
+	}
+
+	// The tag protects the integrity of the data
+	envelope, _ := base64.StdEncoding.DecodeString(string(cphrd))
+	envelope[len(envelope)-1] ^= 1
+	if _, err := aesGCMDecrypt([]byte(base64.StdEncoding.EncodeToString(envelope)), aesData); err == nil {
+		t.Fatal("Tampered data should not be decrypted")
+	}
+
+	if _, err := aesGCMDecrypt(cphrd, models.EncryptionDetails{Key: "other"}); err == nil {
+		t.Fatal("Data should not be decrypted with another key")
+	}
+}
+
+func TestHKDF(t *testing.T) {
+	// RFC 5869 test case 1
+	secret, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	expected := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+
+	if okm := hex.EncodeToString(hkdf(secret, salt, info, 42)); okm != expected {
+		t.Fatalf("Derived key should be %s, not %s", expected, okm)
+	}
+}
@@ -8,6 +8,11 @@ package distro
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
 	contract "github.com/Circutor/edgex/pkg/models"
 )
@@ -75,3 +80,234 @@ func (filter valueDescFilterDetails) Filter(event *contract.Event) (bool, *contr
 	}
 	return len(auxEvent.Readings) > 0, auxEvent
 }
+
+// Event with other readings
+func withReadings(event *contract.Event, readings []contract.Reading) *contract.Event {
+	auxEvent := *event
+	auxEvent.Readings = readings
+	return &auxEvent
+}
+
+type devicePatternFilter struct {
+	pattern *regexp.Regexp
+}
+
+func newDevicePatternFilter(filter contract.Filter) (filterer, error) {
+	pattern, err := regexp.Compile(filter.DevicePattern)
+	if err != nil {
+		return nil, err
+	}
+	return devicePatternFilter{pattern: pattern}, nil
+}
+
+func (filter devicePatternFilter) Filter(event *contract.Event) (bool, *contract.Event) {
+	if event == nil {
+		return false, nil
+	}
+	return filter.pattern.MatchString(event.Device), event
+}
+
+type readingPatternFilter struct {
+	pattern *regexp.Regexp
+}
+
+func newReadingPatternFilter(filter contract.Filter) (filterer, error) {
+	pattern, err := regexp.Compile(filter.ReadingPattern)
+	if err != nil {
+		return nil, err
+	}
+	return readingPatternFilter{pattern: pattern}, nil
+}
+
+func (filter readingPatternFilter) Filter(event *contract.Event) (bool, *contract.Event) {
+	if event == nil {
+		return false, nil
+	}
+
+	var readings []contract.Reading
+	for _, reading := range event.Readings {
+		if filter.pattern.MatchString(reading.Name) {
+			readings = append(readings, reading)
+		}
+	}
+	return len(readings) > 0, withReadings(event, readings)
+}
+
+// Accepts the events of the devices with all the labels in metadata
+type deviceLabelFilter struct {
+	labels []string
+}
+
+func newDeviceLabelFilter(filter contract.Filter) filterer {
+	return deviceLabelFilter{labels: filter.DeviceLabels}
+}
+
+func (filter deviceLabelFilter) Filter(event *contract.Event) (bool, *contract.Event) {
+	if event == nil {
+		return false, nil
+	}
+
+	labels, err := deviceLabels(event.Device)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return false, event
+	}
+	for _, label := range filter.labels {
+		key, value := label, ""
+		if i := strings.IndexAny(label, "=:"); i > 0 {
+			key, value = label[:i], label[i+1:]
+		}
+		if v, ok := labels[key]; !ok || (value != "" && v != value) {
+			return false, event
+		}
+	}
+	return true, event
+}
+
+// Keeps the readings satisfying all the numeric conditions on them
+type valueFilter struct {
+	conditions []contract.ValueCondition
+}
+
+func newValueFilter(filter contract.Filter) filterer {
+	return valueFilter{conditions: filter.Values}
+}
+
+func (filter valueFilter) Filter(event *contract.Event) (bool, *contract.Event) {
+	if event == nil {
+		return false, nil
+	}
+
+	var readings []contract.Reading
+	for _, reading := range event.Readings {
+		if filter.accepts(reading) {
+			readings = append(readings, reading)
+		}
+	}
+	return len(readings) > 0, withReadings(event, readings)
+}
+
+func (filter valueFilter) accepts(reading contract.Reading) bool {
+	for _, c := range filter.conditions {
+		if c.Reading != "" && c.Reading != reading.Name {
+			continue
+		}
+		value, err := strconv.ParseFloat(reading.Value, 64)
+		if err != nil || !compare(value, c.Operator, c.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func compare(value float64, operator string, reference float64) bool {
+	switch operator {
+	case contract.OpGreater:
+		return value > reference
+	case contract.OpGreaterEqual:
+		return value >= reference
+	case contract.OpLess:
+		return value < reference
+	case contract.OpLessEqual:
+		return value <= reference
+	case contract.OpEqual:
+		return value == reference
+	case contract.OpNotEqual:
+		return value != reference
+	}
+	return false
+}
+
+// Report by exception, keeps the readings that changed more than their deadband since the last one delivered
+// Readings without deadband or whose value is not numeric are always kept
+type deadbandFilter struct {
+	deadbands []contract.Deadband
+	mutex     *sync.Mutex
+	last      map[string]float64            // Last value delivered by device and reading
+	pending   map[string]map[string]float64 // Values kept by event, until the event is settled
+}
+
+func newDeadbandFilter(filter contract.Filter) filterer {
+	return deadbandFilter{
+		deadbands: filter.Deadbands,
+		mutex:     &sync.Mutex{},
+		last:      make(map[string]float64),
+		pending:   make(map[string]map[string]float64),
+	}
+}
+
+func (filter deadbandFilter) Filter(event *contract.Event) (bool, *contract.Event) {
+	if event == nil {
+		return false, nil
+	}
+
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+
+	var readings []contract.Reading
+	for _, reading := range event.Readings {
+		if filter.changed(event.ID, event.Device, reading) {
+			readings = append(readings, reading)
+		}
+	}
+	return len(readings) > 0, withReadings(event, readings)
+}
+
+// The values of the readings kept become the reference only once their event is delivered,
+// an event sent again after a failure is compared with the same values
+func (filter deadbandFilter) settle(ids []string, delivered bool) {
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+
+	for _, id := range ids {
+		if delivered {
+			for key, value := range filter.pending[id] {
+				filter.last[key] = value
+			}
+		}
+		delete(filter.pending, id)
+	}
+}
+
+func (filter deadbandFilter) changed(id string, device string, reading contract.Reading) bool {
+	deadband, ok := filter.deadband(reading.Name)
+	if !ok {
+		return true
+	}
+	value, err := strconv.ParseFloat(reading.Value, 64)
+	if err != nil {
+		return true
+	}
+
+	key := device + "/" + reading.Name
+	last, ok := filter.last[key]
+	if ok {
+		change := math.Abs(value - last)
+		exceeded := (deadband.Absolute > 0 && change > deadband.Absolute) ||
+			(deadband.Percent > 0 && last == 0 && change > 0) ||
+			(deadband.Percent > 0 && last != 0 && change*100/math.Abs(last) > deadband.Percent)
+		if !exceeded {
+			return false
+		}
+	}
+	if filter.pending[id] == nil {
+		filter.pending[id] = make(map[string]float64)
+	}
+	filter.pending[id][key] = value
+	return true
+}
+
+// Deadband of a reading, those for a name are preferred over the ones for all the readings
+func (filter deadbandFilter) deadband(name string) (contract.Deadband, bool) {
+	var found bool
+	var deadband contract.Deadband
+	for _, d := range filter.deadbands {
+		if d.Reading == name {
+			return d, true
+		}
+		if d.Reading == "" {
+			deadband, found = d, true
+		}
+	}
+	return deadband, found
+}
@@ -7,9 +7,12 @@
 package distro
 
 import (
+	"strconv"
 	"testing"
 
+	"github.com/Circutor/edgex/pkg/clients/metadata/mocks"
 	contract "github.com/Circutor/edgex/pkg/models"
+	"github.com/stretchr/testify/mock"
 )
 
 const (
@@ -128,3 +131,143 @@ func TestFilterValue(t *testing.T) {
 		t.Fatal("Event should be one reading, there are ", len(res.Readings))
 	}
 }
+
+func TestFilterPatterns(t *testing.T) {
+	event := contract.Event{
+		ID:       "id1",
+		Device:   "meter-12",
+		Readings: []contract.Reading{{Name: "POWER_L1"}, {Name: "VOLTAGE_L1"}, {Name: "POWER_L2"}},
+	}
+
+	devFilter, _ := newDevicePatternFilter(contract.Filter{DevicePattern: "^meter-[0-9]+$"})
+	if accepted, _ := devFilter.Filter(&event); !accepted {
+		t.Fatal("Event should be accepted")
+	}
+	devFilter, _ = newDevicePatternFilter(contract.Filter{DevicePattern: "^inverter"})
+	if accepted, _ := devFilter.Filter(&event); accepted {
+		t.Fatal("Event should be filtered out")
+	}
+
+	readingFilter, _ := newReadingPatternFilter(contract.Filter{ReadingPattern: "^POWER_"})
+	accepted, res := readingFilter.Filter(&event)
+	if !accepted || len(res.Readings) != 2 {
+		t.Fatal("Event should be accepted with two readings")
+	}
+	if res.ID != event.ID || len(event.Readings) != 3 {
+		t.Fatal("Event should be copied")
+	}
+
+	if _, err := newReadingPatternFilter(contract.Filter{ReadingPattern: "("}); err == nil {
+		t.Fatal("Invalid patterns should fail")
+	}
+}
+
+func TestFilterDeviceLabels(t *testing.T) {
+	mdcMock := &mocks.DeviceClient{}
+	mdcMock.On("DeviceForName", "meter3", mock.Anything).Return(contract.Device{Labels: []string{"tenant=acme", "billing"}}, nil)
+	mdc = mdcMock
+	defer func() { mdc = nil }()
+
+	event := contract.Event{Device: "meter3"}
+	var tests = []struct {
+		labels   []string
+		accepted bool
+	}{
+		{[]string{"tenant=acme"}, true},
+		{[]string{"tenant:acme", "billing"}, true},
+		{[]string{"tenant"}, true},
+		{[]string{"tenant=other"}, false},
+		{[]string{"billing", "site"}, false},
+	}
+	for _, tt := range tests {
+		filter := newDeviceLabelFilter(contract.Filter{DeviceLabels: tt.labels})
+		if accepted, _ := filter.Filter(&event); accepted != tt.accepted {
+			t.Errorf("Labels %v should accept the event: %v", tt.labels, tt.accepted)
+		}
+	}
+}
+
+func TestFilterValues(t *testing.T) {
+	filter := newValueFilter(contract.Filter{Values: []contract.ValueCondition{
+		{Reading: "VOLTAGE", Operator: contract.OpGreater, Value: 230},
+		{Operator: contract.OpNotEqual, Value: 0},
+	}})
+
+	event := contract.Event{Readings: []contract.Reading{
+		{Name: "VOLTAGE", Value: "231.2"},
+		{Name: "VOLTAGE", Value: "229"},
+		{Name: "CURRENT", Value: "0"},
+		{Name: "CURRENT", Value: "5"},
+		{Name: "STATE", Value: "on"},
+	}}
+	accepted, res := filter.Filter(&event)
+	if !accepted {
+		t.Fatal("Event should be accepted")
+	}
+	if len(res.Readings) != 2 || res.Readings[0].Value != "231.2" || res.Readings[1].Value != "5" {
+		t.Fatalf("Only the readings satisfying the conditions should be kept: %v", res.Readings)
+	}
+
+	if accepted, _ := filter.Filter(&contract.Event{Readings: []contract.Reading{{Name: "VOLTAGE", Value: "220"}}}); accepted {
+		t.Fatal("Event should be filtered out")
+	}
+}
+
+func TestFilterDeadband(t *testing.T) {
+	filter := newDeadbandFilter(contract.Filter{Deadbands: []contract.Deadband{
+		{Reading: "POWER", Percent: 5},
+		{Absolute: 1},
+	}})
+
+	var tests = []struct {
+		device   string
+		name     string
+		value    string
+		accepted bool
+	}{
+		{"meter1", "POWER", "100", true},
+		{"meter1", "POWER", "104", false},
+		{"meter1", "POWER", "94", true},
+		{"meter1", "POWER", "97", false},
+		{"meter2", "POWER", "97", true},
+		{"meter1", "VOLTAGE", "230", true},
+		{"meter1", "VOLTAGE", "230.9", false},
+		{"meter1", "VOLTAGE", "228.5", true},
+		{"meter1", "STATE", "on", true},
+		{"meter1", "STATE", "on", true},
+	}
+	for i, tt := range tests {
+		id := strconv.Itoa(i)
+		event := contract.Event{ID: id, Device: tt.device, Readings: []contract.Reading{{Name: tt.name, Value: tt.value}}}
+		if accepted, _ := filter.Filter(&event); accepted != tt.accepted {
+			t.Errorf("Reading %d %s=%s should be accepted: %v", i, tt.name, tt.value, tt.accepted)
+		}
+		filter.(settledFilterer).settle([]string{id}, true)
+	}
+}
+
+func TestFilterDeadbandUndelivered(t *testing.T) {
+	filter := newDeadbandFilter(contract.Filter{Deadbands: []contract.Deadband{{Percent: 5}}})
+
+	var tests = []struct {
+		value     string
+		accepted  bool
+		delivered bool
+	}{
+		{"0", true, true},
+		{"0", false, false},
+		{"10", true, false},
+		{"10", true, true}, // Sent again after the failure
+		{"10", false, false},
+		{"0", true, true},
+		{"0.1", true, true}, // Any change from 0 exceeds a percentage
+	}
+	for i, tt := range tests {
+		id := strconv.Itoa(i)
+		event := contract.Event{ID: id, Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: tt.value}}}
+		if accepted, _ := filter.Filter(&event); accepted != tt.accepted {
+			t.Errorf("Reading %d POWER=%s should be accepted: %v", i, tt.value, tt.accepted)
+		}
+		filter.(settledFilterer).settle([]string{id}, tt.delivered)
+	}
+}
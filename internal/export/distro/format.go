@@ -9,12 +9,15 @@
 package distro
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	contract "github.com/Circutor/edgex/pkg/models"
 	"github.com/google/uuid"
@@ -40,6 +43,16 @@ func (jsonTr jsonFormatter) Format(event *contract.Event) []byte {
 	return b
 }
 
+// JSON array of the events
+func (jsonTr jsonFormatter) FormatBatch(events []*contract.Event) []byte {
+	b, err := json.Marshal(events)
+	if err != nil {
+		LoggingClient.Error(fmt.Sprintf("Error parsing JSON. Error: %s", err.Error()))
+		return nil
+	}
+	return b
+}
+
 type xmlFormatter struct {
 }
 
@@ -55,26 +68,28 @@ func (xmlTr xmlFormatter) Format(event *contract.Event) []byte {
 type thingsboardJSONFormatter struct {
 }
 
+type thingsboardValues struct {
+	Ts     int64             `json:"ts"`
+	Values map[string]string `json:"values"`
+}
+
 // ThingsBoard JSON formatter
 // https://thingsboard.io/docs/reference/gateway-mqtt-api/#telemetry-upload-api
 func (thingsboardjsonTr thingsboardJSONFormatter) Format(event *contract.Event) []byte {
+	return thingsboardjsonTr.FormatBatch([]*contract.Event{event})
+}
 
-	type Device struct {
-		Ts     int64             `json:"ts"`
-		Values map[string]string `json:"values"`
-	}
-
-	values := make(map[string]string)
-	for _, reading := range event.Readings {
-		values[reading.Name] = reading.Value
+// Values of all the events grouped by device
+func (thingsboardjsonTr thingsboardJSONFormatter) FormatBatch(events []*contract.Event) []byte {
+	device := make(map[string][]thingsboardValues)
+	for _, event := range events {
+		values := make(map[string]string)
+		for _, reading := range event.Readings {
+			values[reading.Name] = reading.Value
+		}
+		device[event.Device] = append(device[event.Device], thingsboardValues{Ts: event.Origin, Values: values})
 	}
 
-	var deviceValues []Device
-	deviceValues = append(deviceValues, Device{Ts: event.Origin, Values: values})
-
-	device := make(map[string][]Device)
-	device[event.Device] = deviceValues
-
 	b, err := json.Marshal(device)
 	if err != nil {
 		LoggingClient.Error(fmt.Sprintf("Error parsing ThingsBoard JSON. Error: %s", err.Error()))
@@ -84,45 +99,66 @@ func (thingsboardjsonTr thingsboardJSONFormatter) Format(event *contract.Event)
 }
 
 type dexmaJSONFormatter struct {
+	parameters map[string]int // Parameter codes by reading name
+	sequences  dexmaSequences // Sequence numbers of the devices and of the events not delivered
+}
+
+type dexmaValue struct {
+	P int     `json:"p"`
+	V float64 `json:"v"`
+}
+
+type dexmaDevice struct {
+	Did    string       `json:"did"`
+	Sqn    uint64       `json:"sqn"`
+	Ts     string       `json:"ts"`
+	Values []dexmaValue `json:"values"`
+}
+
+func newDexmaJSONFormatter(registration string, parameters map[string]int) (dexmaJSONFormatter, error) {
+	sequences, err := newDexmaSequences(registration)
+	if err != nil {
+		return dexmaJSONFormatter{}, err
+	}
+	return dexmaJSONFormatter{parameters: parameters, sequences: sequences}, nil
 }
 
 // Dexma JSON formatter
 //http://support.dexmatech.com/customer/en/portal/articles/1745389-http-json-api-data-insertion-
 func (dexmajsonTr dexmaJSONFormatter) Format(event *contract.Event) []byte {
+	return dexmajsonTr.FormatBatch([]*contract.Event{event})
+}
 
-	type Value struct {
-		P int `json:"p"`
-		V int `json:"v"`
+// The Dexma API accepts the values of several devices and timestamps at once
+// Each event advances the sequence number of its device the first time it is formatted
+func (dexmajsonTr dexmaJSONFormatter) FormatBatch(events []*contract.Event) []byte {
+	sqns, err := dexmajsonTr.sequences.next(events)
+	if err != nil {
+		LoggingClient.Error(fmt.Sprintf("Error storing Dexma sequence numbers. Error: %s", err.Error()))
+		return nil
 	}
 
-	type Device struct {
-		Did    string  `json:"did"`
-		Sqn    int     `json:"sqn"`
-		Ts     string  `json:"ts"`
-		Values []Value `json:"values"`
+	var devices []dexmaDevice
+	for i, event := range events {
+		devices = append(devices, dexmajsonTr.device(event, sqns[i]))
 	}
+	return dexmajsonTr.marshal(devices)
+}
 
-	var value Value
-	var values []Value
+// Length of the formatted event, without advancing the sequence number of its device
+func (dexmajsonTr dexmaJSONFormatter) size(event *contract.Event) int {
+	sqn := dexmajsonTr.sequences.peek(event)
+	return len(dexmajsonTr.marshal([]dexmaDevice{dexmajsonTr.device(event, sqn)}))
+}
 
-	for _, reading := range event.Readings {
-		var err error
-		value.P, err = strconv.Atoi(reading.Value)
-		if err != nil {
-			value.P = transformDexmaParam(reading.Name)
-		}
-		value.V, _ = strconv.Atoi(reading.Value)
-		if value.P == 0 {
-			LoggingClient.Error(fmt.Sprintf("Error on Dexma parameter name: %s", reading.Name))
-		} else {
-			values = append(values, value)
-		}
+// Forget the sequence numbers of the events delivered, they are not sent again
+func (dexmajsonTr dexmaJSONFormatter) settle(events []*contract.Event) {
+	if err := dexmajsonTr.sequences.release(events); err != nil {
+		LoggingClient.Error(fmt.Sprintf("Error releasing Dexma sequence numbers. Error: %s", err.Error()))
 	}
+}
 
-	var devices []Device
-	time := time.Unix(event.Origin/1000, 0).Format(time.RFC3339)
-	devices = append(devices, Device{Did: event.Device, Sqn: 1, Ts: time, Values: values})
-
+func (dexmajsonTr dexmaJSONFormatter) marshal(devices []dexmaDevice) []byte {
 	b, err := json.Marshal(devices)
 	if err != nil {
 		LoggingClient.Error(fmt.Sprintf("Error parsing Dexma JSON. Error: %s", err.Error()))
@@ -131,6 +167,28 @@ func (dexmajsonTr dexmaJSONFormatter) Format(event *contract.Event) []byte {
 	return b
 }
 
+// Values of an event with its sequence number
+// The readings without parameter code or numeric value are left out
+func (dexmajsonTr dexmaJSONFormatter) device(event *contract.Event, sqn uint64) dexmaDevice {
+	values := []dexmaValue{}
+	for _, reading := range event.Readings {
+		code, ok := dexmajsonTr.parameters[reading.Name]
+		if !ok {
+			LoggingClient.Error(fmt.Sprintf("Error on Dexma parameter name: %s", reading.Name))
+			continue
+		}
+		value, err := strconv.ParseFloat(reading.Value, 64)
+		if err != nil {
+			LoggingClient.Error(fmt.Sprintf("Error on Dexma value of %s: %s", reading.Name, reading.Value))
+			continue
+		}
+		values = append(values, dexmaValue{P: code, V: value})
+	}
+
+	time := time.Unix(event.Origin/1000, 0).Format(time.RFC3339)
+	return dexmaDevice{Did: event.Device, Sqn: sqn, Ts: time, Values: values}
+}
+
 // Azure IoT Hub message
 // https://docs.microsoft.com/en-us/azure/iot-hub/iot-hub-devguide-messages-construct
 type connAuthMethod struct {
@@ -259,6 +317,94 @@ func (noopFmt noopFormatter) Format(event *contract.Event) []byte {
 	return []byte{}
 }
 
+// CSV formatter writing a row per reading
+type csvFormatter struct {
+	options contract.CSVOptions
+}
+
+func newCSVFormatter(options contract.CSVOptions) csvFormatter {
+	if len(options.Columns) == 0 {
+		options.Columns = []string{contract.CSVDevice, contract.CSVName, contract.CSVValue, contract.CSVOrigin}
+	}
+	return csvFormatter{options: options}
+}
+
+func (csvFmt csvFormatter) Format(event *contract.Event) []byte {
+	return csvFmt.FormatBatch([]*contract.Event{event})
+}
+
+// The header, if any, is only written once
+func (csvFmt csvFormatter) FormatBatch(events []*contract.Event) []byte {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if csvFmt.options.Delimiter != "" {
+		w.Comma, _ = utf8.DecodeRuneInString(csvFmt.options.Delimiter)
+	}
+
+	if csvFmt.options.Header {
+		w.Write(csvFmt.options.Columns)
+	}
+	for _, event := range events {
+		for _, reading := range event.Readings {
+			row := make([]string, len(csvFmt.options.Columns))
+			for i, column := range csvFmt.options.Columns {
+				row[i] = csvFmt.value(column, event, reading)
+			}
+			w.Write(row)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		LoggingClient.Error(fmt.Sprintf("Error writing CSV. Error: %s", err.Error()))
+		return nil
+	}
+	return b.Bytes()
+}
+
+// Value of a column for a reading
+func (csvFmt csvFormatter) value(column string, event *contract.Event, reading contract.Reading) string {
+	switch column {
+	case contract.CSVDevice:
+		if reading.Device != "" {
+			return reading.Device
+		}
+		return event.Device
+	case contract.CSVName:
+		return reading.Name
+	case contract.CSVValue:
+		return reading.Value
+	case contract.CSVOrigin:
+		origin := reading.Origin
+		if origin == 0 {
+			origin = event.Origin
+		}
+		return formatTimestamp(origin, csvFmt.options.TimestampFormat)
+	case contract.CSVMin:
+		return reading.MinValue
+	case contract.CSVAvg:
+		return reading.AvgValue
+	case contract.CSVMax:
+		return reading.MaxValue
+	}
+	return ""
+}
+
+// Format a timestamp in milliseconds
+func formatTimestamp(millis int64, format string) string {
+	t := time.Unix(0, millis*int64(time.Millisecond)).UTC()
+	switch format {
+	case "", contract.TimestampMillis:
+		return strconv.FormatInt(millis, 10)
+	case contract.TimestampSeconds:
+		return strconv.FormatInt(millis/1000, 10)
+	case contract.TimestampRFC3339:
+		return t.Format(time.RFC3339Nano)
+	default:
+		return t.Format(format)
+	}
+}
+
 // BIoTMessage represents Brightics IoT(Samsung SDS IoT platform)  messages.
 type BIoTMessage struct {
 	Version    string `json:"version"`
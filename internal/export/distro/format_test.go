@@ -9,6 +9,9 @@ package distro
 import (
 	"encoding/json"
 	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -81,12 +84,31 @@ func TestThingsBoardJson(t *testing.T) {
 
 }
 
+// Open the Dexma sequence numbers in a temporary file
+func openTestDexmaSequences(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "dexma")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %v", err)
+	}
+	Configuration.Dexma.SequenceFile = filepath.Join(dir, "dexma.db")
+	return func() {
+		closeDexmaSequences()
+		Configuration.Dexma.SequenceFile = ""
+		os.RemoveAll(dir)
+	}
+}
+
 func TestDexmaJson(t *testing.T) {
+	defer openTestDexmaSequences(t)()
+
 	eventIn := contract.Event{
 		Device: devID1,
 	}
 
-	djf := dexmaJSONFormatter{}
+	djf, err := newDexmaJSONFormatter("dexma", nil)
+	if err != nil {
+		t.Fatalf("Error creating Dexma formatter: %v", err)
+	}
 	out := djf.Format(&eventIn)
 	if out == nil {
 		t.Fatal("out should not be nil")
@@ -166,3 +188,215 @@ func TestBIoT(t *testing.T) {
 		t.Fatalf("Error unmarshal the formatted string: %v %v", err, out)
 	}
 }
+
+func TestCSV(t *testing.T) {
+	eventIn := contract.Event{
+		Device: devID1,
+		Origin: 1577836800000,
+		Readings: []contract.Reading{
+			{Name: readingName1, Value: readingValue1, MinValue: "1", AvgValue: "2", MaxValue: "3"},
+			{Name: "sensor2", Value: "a;b", Origin: 1577836801500},
+		},
+	}
+
+	var tests = []struct {
+		name     string
+		options  contract.CSVOptions
+		expected string
+	}{
+		{"default", contract.CSVOptions{},
+			"id1,sensor1,123.45,1577836800000\nid1,sensor2,a;b,1577836801500\n"},
+		{"header", contract.CSVOptions{Delimiter: ";", Header: true, Columns: []string{"name", "min", "avg", "max"}},
+			"name;min;avg;max\nsensor1;1;2;3\nsensor2;;;\n"},
+		{"quoted", contract.CSVOptions{Delimiter: ";", Columns: []string{"value"}},
+			"123.45\n\"a;b\"\n"},
+		{"seconds", contract.CSVOptions{Columns: []string{"origin"}, TimestampFormat: contract.TimestampSeconds},
+			"1577836800\n1577836801\n"},
+		{"rfc3339", contract.CSVOptions{Columns: []string{"origin"}, TimestampFormat: contract.TimestampRFC3339},
+			"2020-01-01T00:00:00Z\n2020-01-01T00:00:01.5Z\n"},
+		{"layout", contract.CSVOptions{Columns: []string{"origin"}, TimestampFormat: "2006-01-02 15:04:05"},
+			"2020-01-01 00:00:00\n2020-01-01 00:00:01\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := newCSVFormatter(tt.options).Format(&eventIn)
+			if string(out) != tt.expected {
+				t.Errorf("Invalid CSV format: %q, expected %q", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatBatch(t *testing.T) {
+	defer openTestDexmaSequences(t)()
+
+	events := []*contract.Event{
+		{Device: devID1, Origin: 1000, Readings: []contract.Reading{{Name: readingName1, Value: "1"}}},
+		{Device: devID1, Origin: 2000, Readings: []contract.Reading{{Name: readingName1, Value: "2"}}},
+		{Device: "id2", Origin: 3000, Readings: []contract.Reading{{Name: readingName1, Value: "3"}}},
+	}
+
+	var array []map[string]interface{}
+	if err := json.Unmarshal(jsonFormatter{}.FormatBatch(events), &array); err != nil || len(array) != 3 {
+		t.Errorf("JSON batch should be an array of the events")
+	}
+
+	djf, _ := newDexmaJSONFormatter("dexma", nil)
+	var devices []map[string]interface{}
+	if err := json.Unmarshal(djf.FormatBatch(events), &devices); err != nil || len(devices) != 3 {
+		t.Errorf("Dexma batch should be an array of the events")
+	}
+
+	var telemetry map[string][]thingsboardValues
+	if err := json.Unmarshal(thingsboardJSONFormatter{}.FormatBatch(events), &telemetry); err != nil {
+		t.Fatalf("Invalid ThingsBoard batch: %v", err)
+	}
+	if len(telemetry[devID1]) != 2 || len(telemetry["id2"]) != 1 || telemetry[devID1][1].Values[readingName1] != "2" {
+		t.Errorf("ThingsBoard batch should group the values by device: %v", telemetry)
+	}
+
+	out := newCSVFormatter(contract.CSVOptions{Header: true, Columns: []string{"device", "value"}}).FormatBatch(events)
+	if string(out) != "device,value\nid1,1\nid1,2\nid2,3\n" {
+		t.Errorf("CSV batch should have a single header: %q", out)
+	}
+}
+
+func TestDexmaValues(t *testing.T) {
+	defer openTestDexmaSequences(t)()
+
+	parameters, err := dexmaParameters(map[string]int{"TEMPERATURE": 501, "POWER": 499})
+	if err != nil {
+		t.Fatalf("Error getting Dexma parameters: %v", err)
+	}
+	djf, err := newDexmaJSONFormatter("dexma", parameters)
+	if err != nil {
+		t.Fatalf("Error creating Dexma formatter: %v", err)
+	}
+
+	eventIn := contract.Event{
+		Device: devID1,
+		Readings: []contract.Reading{
+			{Name: "VOLTAGE", Value: "230.4"},
+			{Name: "POWER", Value: "1500"},
+			{Name: "TEMPERATURE", Value: "21.5"},
+			{Name: "UNKNOWN", Value: "1"},
+			{Name: "CURRENT", Value: "off"},
+		},
+	}
+
+	var devices []dexmaDevice
+	if err := json.Unmarshal(djf.Format(&eventIn), &devices); err != nil {
+		t.Fatalf("Invalid Dexma JSON: %v", err)
+	}
+	expected := []dexmaValue{{P: 405, V: 230.4}, {P: 499, V: 1500}, {P: 501, V: 21.5}}
+	if !reflect.DeepEqual(devices[0].Values, expected) {
+		t.Errorf("Dexma values should be %v, not %v", expected, devices[0].Values)
+	}
+
+	if err := json.Unmarshal(djf.FormatBatch([]*contract.Event{&eventIn, &eventIn}), &devices); err != nil {
+		t.Fatalf("Invalid Dexma JSON: %v", err)
+	}
+	if devices[0].Sqn != 2 || devices[1].Sqn != 3 {
+		t.Errorf("Each event should have the next sequence number: %d, %d", devices[0].Sqn, devices[1].Sqn)
+	}
+
+	// Measuring an event does not use a sequence number
+	if djf.size(&eventIn) != len(djf.Format(&eventIn)) {
+		t.Errorf("The size should be the length of the formatted event")
+	}
+	other, _ := newDexmaJSONFormatter("other", parameters)
+	if err := json.Unmarshal(other.Format(&eventIn), &devices); err != nil || devices[0].Sqn != 1 {
+		t.Errorf("Each registration should have its own sequence numbers")
+	}
+
+	// The sequence numbers survive the restarts
+	closeDexmaSequences()
+	djf, _ = newDexmaJSONFormatter("dexma", parameters)
+	if err := json.Unmarshal(djf.Format(&eventIn), &devices); err != nil || devices[0].Sqn != 5 {
+		t.Errorf("The sequence number should be kept after a restart, not %d", devices[0].Sqn)
+	}
+}
+
+func TestDexmaSequenceRetry(t *testing.T) {
+	defer openTestDexmaSequences(t)()
+
+	djf, err := newDexmaJSONFormatter("dexma", nil)
+	if err != nil {
+		t.Fatalf("Error creating Dexma formatter: %v", err)
+	}
+	sqn := func(events ...*contract.Event) []uint64 {
+		var devices []dexmaDevice
+		if err := json.Unmarshal(djf.FormatBatch(events), &devices); err != nil {
+			t.Fatalf("Invalid Dexma JSON: %v", err)
+		}
+		var sqns []uint64
+		for _, device := range devices {
+			sqns = append(sqns, device.Sqn)
+		}
+		return sqns
+	}
+
+	first := &contract.Event{ID: "a", Device: devID1, Readings: []contract.Reading{{Name: "POWER", Value: "1"}}}
+	second := &contract.Event{ID: "b", Device: devID1, Readings: []contract.Reading{{Name: "POWER", Value: "2"}}}
+	if got := sqn(first); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Fatalf("The first event should have the first sequence number, not %v", got)
+	}
+
+	// An event sent again keeps its number, in a batch or after a restart
+	if got := sqn(second, first); !reflect.DeepEqual(got, []uint64{2, 1}) {
+		t.Errorf("The event retried should keep its sequence number: %v", got)
+	}
+	closeDexmaSequences()
+	djf, _ = newDexmaJSONFormatter("dexma", nil)
+	if djf.size(first) != len(djf.Format(first)) {
+		t.Errorf("The size should be the one of the event with its sequence number")
+	}
+	if got := sqn(first, second); !reflect.DeepEqual(got, []uint64{1, 2}) {
+		t.Errorf("The sequence numbers should be kept after a restart: %v", got)
+	}
+
+	// The parts of a split event have their own numbers
+	part := *first
+	part.Readings = []contract.Reading{{Name: "VOLTAGE", Value: "230"}}
+	if got := sqn(&part); !reflect.DeepEqual(got, []uint64{3}) {
+		t.Errorf("The part of the event should have its own sequence number: %v", got)
+	}
+
+	// Once delivered, the same ID is a new event
+	djf.settle([]*contract.Event{first})
+	if got := sqn(first, second); !reflect.DeepEqual(got, []uint64{4, 2}) {
+		t.Errorf("Only the events not delivered should keep their numbers: %v", got)
+	}
+
+	// The numbers are not taken once the store is closed
+	closeDexmaSequences()
+	if out := djf.Format(first); out != nil {
+		t.Errorf("Events should not be formatted once the sequence numbers are closed: %s", out)
+	}
+}
+
+func TestDexmaParametersFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "dexma")
+	if err != nil {
+		t.Fatalf("Error creating file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"HUMIDITY": 502, "VOLTAGE": 450}`)
+	file.Close()
+
+	Configuration.Dexma.ParametersFile = file.Name()
+	defer func() { Configuration.Dexma.ParametersFile = "" }()
+
+	parameters, err := dexmaParameters(map[string]int{"VOLTAGE": 460})
+	if err != nil {
+		t.Fatalf("Error getting Dexma parameters: %v", err)
+	}
+	if parameters["HUMIDITY"] != 502 || parameters["VOLTAGE"] != 460 || parameters["POWER"] != 401 {
+		t.Errorf("Parameters of the file and registration should override the builtin ones: %v", parameters)
+	}
+
+	Configuration.Dexma.ParametersFile = file.Name() + ".missing"
+	if _, err := dexmaParameters(nil); err == nil {
+		t.Errorf("Missing parameter files should fail")
+	}
+}
@@ -12,6 +12,7 @@ package distro
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,50 +25,170 @@ import (
 )
 
 type httpSender struct {
-	url    string
-	method string
+	url     string
+	method  string
+	addr    contract.Addressable // Headers and authentication
+	content httpContent
+	client  *http.Client
 }
 
-const mimeTypeJSON = "application/json"
+// Headers describing the payloads of a registration
+type httpContent struct {
+	contentType string
+	encoding    string // Content-Encoding of the compressed payloads, they are sent decoded from base64
+}
+
+const (
+	mimeTypeJSON   = "application/json"
+	mimeTypeXML    = "application/xml"
+	mimeTypeCSV    = "text/csv"
+	mimeTypeText   = "text/plain"
+	mimeTypeBinary = "application/octet-stream"
+
+	defaultAPIKeyHeader = "X-API-Key"
+	httpTimeout         = 30 * time.Second
+)
+
+// Return the headers of the payloads of the registration
+func newHTTPContent(reg contract.Registration) httpContent {
+	// Encrypted payloads are base64 text
+	if reg.Encryption.Algo != "" && reg.Encryption.Algo != contract.EncNone {
+		return httpContent{contentType: mimeTypeText}
+	}
+
+	content := httpContent{contentType: mimeTypeJSON}
+	switch reg.Format {
+	case contract.FormatXML:
+		content.contentType = mimeTypeXML
+	case contract.FormatCSV:
+		content.contentType = mimeTypeCSV
+	case contract.FormatNOOP:
+		content.contentType = mimeTypeBinary
+	}
+
+	switch reg.Compression {
+	case contract.CompGzip:
+		content.encoding = "gzip"
+	case contract.CompZip:
+		content.encoding = "deflate"
+	}
+	return content
+}
 
 // newHTTPSender - create http sender
-func newHTTPSender(addr contract.Addressable) sender {
+func newHTTPSender(addr contract.Addressable, content httpContent) sender {
+	url := addr.Protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + addr.Path
+	return newHTTPSenderWithURL(url, addr, content)
+}
 
-	sender := httpSender{
-		url:    addr.Protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + addr.Path,
-		method: addr.HTTPMethod,
+func newHTTPSenderWithURL(url string, addr contract.Addressable, content httpContent) sender {
+	switch addr.AuthType {
+	case "", contract.AuthBasic, contract.AuthBearer, contract.AuthAPIKey:
+	default:
+		LoggingClient.Error(fmt.Sprintf("Authentication not supported: %s", addr.AuthType))
+		return nil
+	}
+
+	client, err := newHTTPClient(addr)
+	if err != nil {
+		LoggingClient.Error(fmt.Sprintf("Failed configuring http client: %s", err.Error()))
+		return nil
+	}
+
+	if content.contentType == "" {
+		content.contentType = mimeTypeJSON
+	}
+	return &httpSender{
+		url:     url,
+		method:  addr.HTTPMethod,
+		addr:    addr,
+		content: content,
+		client:  client,
 	}
-	return sender
 }
 
-// Send will send the optionally filtered, compressed, encypted contract.Event via HTTP POST
-// The model.Event is provided in order to obtain the necessary correlation-id.
-func (sender httpSender) Send(data []byte, event *models.Event) bool {
+// Create the client with the timeout and TLS options of the addressable
+func newHTTPClient(addr contract.Addressable) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := httpTimeout
+	if addr.Timeout > 0 {
+		timeout = time.Duration(addr.Timeout) * time.Millisecond
+	}
 
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// Send will send the optionally filtered, compressed, encypted contract.Event via HTTP POST, PUT or PATCH
+// The model.Event is provided in order to obtain the necessary correlation-id.
+func (sender *httpSender) Send(data []byte, event *models.Event) bool {
 	switch sender.method {
-	case http.MethodPost:
-		ctx := context.WithValue(context.Background(), clients.CorrelationHeader, event.CorrelationId)
-		req, err := http.NewRequest(http.MethodPost, sender.url, bytes.NewReader(data))
-		if err != nil {
-			return false
-		}
-		req.Header.Set("Content-Type", mimeTypeJSON)
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		LoggingClient.Info(fmt.Sprintf("Unsupported method: %s", sender.method))
+		return false
+	}
 
-		c := clients.NewCorrelatedRequest(req, ctx)
-		client := &http.Client{}
-		begin := time.Now()
-		response, err := client.Do(c.Request)
+	body := data
+	if sender.content.encoding != "" {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
 		if err != nil {
-			LoggingClient.Error(err.Error(), clients.CorrelationHeader, event.CorrelationId, internal.LogDurationKey, time.Since(begin).String())
+			LoggingClient.Error(fmt.Sprintf("Failed decoding compressed data: %s", err.Error()))
 			return false
 		}
-		defer response.Body.Close()
-		LoggingClient.Info(fmt.Sprintf("Response: %s", response.Status), clients.CorrelationHeader, event.CorrelationId, internal.LogDurationKey, time.Since(begin).String())
-	default:
-		LoggingClient.Info(fmt.Sprintf("Unsupported method: %s", sender.method))
+		body = decoded
+	}
+
+	ctx := context.WithValue(context.Background(), clients.CorrelationHeader, event.CorrelationId)
+	req, err := http.NewRequest(sender.method, sender.url, bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	sender.setHeaders(req)
+
+	c := clients.NewCorrelatedRequest(req, ctx)
+	begin := time.Now()
+	response, err := sender.client.Do(c.Request)
+	if err != nil {
+		LoggingClient.Error(err.Error(), clients.CorrelationHeader, event.CorrelationId, internal.LogDurationKey, time.Since(begin).String())
+		return false
+	}
+	defer response.Body.Close()
+	LoggingClient.Info(fmt.Sprintf("Response: %s", response.Status), clients.CorrelationHeader, event.CorrelationId, internal.LogDurationKey, time.Since(begin).String())
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
 		return false
 	}
 
 	LoggingClient.Debug(fmt.Sprintf("Sent data: %X", data))
 	return true
 }
+
+// Set the content, additional and authentication headers of a request
+func (sender *httpSender) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", sender.content.contentType)
+	if sender.content.encoding != "" {
+		req.Header.Set("Content-Encoding", sender.content.encoding)
+	}
+
+	for name, value := range sender.addr.Headers {
+		req.Header.Set(name, value)
+	}
+
+	switch sender.addr.AuthType {
+	case contract.AuthBasic:
+		req.SetBasicAuth(sender.addr.User, sender.addr.Password)
+	case contract.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+sender.addr.Token)
+	case contract.AuthAPIKey:
+		header := sender.addr.AuthHeader
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+		req.Header.Set(header, sender.addr.Token)
+	}
+}
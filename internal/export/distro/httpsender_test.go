@@ -8,6 +8,8 @@ package distro
 
 import (
 	"bytes"
+	"compress/gzip"
+	"encoding/pem"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -41,6 +43,14 @@ func TestHttpSender(t *testing.T) {
 			Protocol:   "http",
 			HTTPMethod: http.MethodPost,
 			Path:       path}},
+		{"put", contract.Addressable{
+			Protocol:   "http",
+			HTTPMethod: http.MethodPut,
+			Path:       path}},
+		{"patch", contract.Addressable{
+			Protocol:   "http",
+			HTTPMethod: http.MethodPatch,
+			Path:       path}},
 		{"postInvalidPort", contract.Addressable{
 			Protocol:   "http",
 			HTTPMethod: http.MethodPost,
@@ -99,10 +109,128 @@ func TestHttpSender(t *testing.T) {
 			if addressableTest.Port == 0 {
 				addressableTest.Port = port
 			}
-			sender := newHTTPSender(addressableTest)
+			sender := newHTTPSender(addressableTest, httpContent{})
 
 			e := models.Event{CorrelationId: "test"}
 			sender.Send(msg, &e)
 		})
 	}
 }
+
+func TestHttpSenderHeaders(t *testing.T) {
+	var tests = []struct {
+		name    string
+		addr    contract.Addressable
+		reg     contract.Registration
+		headers map[string]string
+	}{
+		{"json", contract.Addressable{},
+			contract.Registration{Format: contract.FormatJSON},
+			map[string]string{"Content-Type": mimeTypeJSON}},
+		{"xmlGzip", contract.Addressable{},
+			contract.Registration{Format: contract.FormatXML, Compression: contract.CompGzip},
+			map[string]string{"Content-Type": mimeTypeXML, "Content-Encoding": "gzip"}},
+		{"encrypted", contract.Addressable{},
+			contract.Registration{Format: contract.FormatJSON, Compression: contract.CompZip, Encryption: contract.EncryptionDetails{Algo: contract.EncAes}},
+			map[string]string{"Content-Type": mimeTypeText, "Content-Encoding": ""}},
+		{"custom", contract.Addressable{Headers: map[string]string{"X-Site": "plant1"}},
+			contract.Registration{},
+			map[string]string{"X-Site": "plant1"}},
+		{"basic", contract.Addressable{AuthType: contract.AuthBasic, User: "user", Password: "pass"},
+			contract.Registration{},
+			map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}},
+		{"bearer", contract.Addressable{AuthType: contract.AuthBearer, Token: "token"},
+			contract.Registration{},
+			map[string]string{"Authorization": "Bearer token"}},
+		{"apiKey", contract.Addressable{AuthType: contract.AuthAPIKey, Token: "key"},
+			contract.Registration{},
+			map[string]string{defaultAPIKeyHeader: "key"}},
+		{"apiKeyHeader", contract.Addressable{AuthType: contract.AuthAPIKey, AuthHeader: "Api-Token", Token: "key"},
+			contract.Registration{},
+			map[string]string{"Api-Token": "key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				for name, value := range tt.headers {
+					if r.Header.Get(name) != value {
+						t.Errorf("Invalid header %s received %s, expected %s", name, r.Header.Get(name), value)
+					}
+				}
+			}
+			ts := httptest.NewServer(http.HandlerFunc(handler))
+			defer ts.Close()
+
+			addr := tt.addr
+			addr.HTTPMethod = http.MethodPost
+			sender := newHTTPSenderWithURL(ts.URL, addr, newHTTPContent(tt.reg))
+
+			data := []byte("test message")
+			if tt.reg.Compression != "" {
+				data = (&gzipTransformer{}).Transform(data)
+			}
+			if !sender.Send(data, &models.Event{}) {
+				t.Errorf("The data should be sent")
+			}
+		})
+	}
+}
+
+func TestHttpSenderGzip(t *testing.T) {
+	msg := []byte("test message")
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatalf("The body should be gzip data: %v", err)
+		}
+		readMsg, _ := ioutil.ReadAll(reader)
+		if bytes.Compare(readMsg, msg) != 0 {
+			t.Errorf("Invalid msg received %v, expected %v", readMsg, msg)
+		}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	reg := contract.Registration{Format: contract.FormatJSON, Compression: contract.CompGzip}
+	sender := newHTTPSenderWithURL(ts.URL, contract.Addressable{HTTPMethod: http.MethodPost}, newHTTPContent(reg))
+	if !sender.Send((&gzipTransformer{}).Transform(msg), &models.Event{}) {
+		t.Errorf("The data should be sent")
+	}
+}
+
+func TestHttpSenderErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	sender := newHTTPSenderWithURL(ts.URL, contract.Addressable{HTTPMethod: http.MethodPost}, httpContent{})
+	if sender.Send([]byte("test message"), &models.Event{}) {
+		t.Errorf("Server errors should fail the send")
+	}
+
+	if newHTTPSenderWithURL(ts.URL, contract.Addressable{AuthType: "DIGEST"}, httpContent{}) != nil {
+		t.Errorf("Unsupported authentication should not create a sender")
+	}
+	if newHTTPSenderWithURL(ts.URL, contract.Addressable{CACertificate: "invalid"}, httpContent{}) != nil {
+		t.Errorf("Invalid CA certificates should not create a sender")
+	}
+}
+
+func TestHttpSenderTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	addr := contract.Addressable{HTTPMethod: http.MethodPost}
+	sender := newHTTPSenderWithURL(ts.URL, addr, httpContent{})
+	if sender.Send([]byte("test message"), &models.Event{}) {
+		t.Errorf("Servers with unknown certificates should not be trusted")
+	}
+
+	addr.CACertificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}))
+	sender = newHTTPSenderWithURL(ts.URL, addr, httpContent{})
+	if !sender.Send([]byte("test message"), &models.Event{}) {
+		t.Errorf("Servers signed by the CA bundle should be trusted")
+	}
+}
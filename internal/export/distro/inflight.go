@@ -0,0 +1,109 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"sync"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Deliveries waiting for an asynchronous sender to confirm their events
+// The sender completes the events from its own goroutines, the deliveries
+// completed are settled from the loop of the registration
+type inFlightDeliveries struct {
+	mutex     sync.Mutex
+	byEvent   map[*models.Event]*inFlightDelivery
+	completed []*inFlightDelivery
+	notify    chan struct{} // Signaled when there are deliveries completed
+}
+
+// Events sent for a delivery, one per reading when they are split
+type inFlightDelivery struct {
+	ids       []string          // Events the delivery comes from
+	keys      [][]byte          // Queue entries of those events
+	formatted []*contract.Event // Events formatted for the delivery
+	count     int               // Events counted as sent or failed
+	pending   int               // Events sent not confirmed yet
+	sending   bool              // More events may be sent for the delivery
+	failed    bool
+}
+
+func newInFlightDeliveries() *inFlightDeliveries {
+	return &inFlightDeliveries{
+		byEvent: make(map[*models.Event]*inFlightDelivery),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+func newInFlightDelivery(ids []string, keys [][]byte, count int) *inFlightDelivery {
+	return &inFlightDelivery{ids: ids, keys: keys, count: count, sending: true}
+}
+
+// Track an event about to be sent for the delivery
+func (d *inFlightDeliveries) add(delivery *inFlightDelivery, event *models.Event) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.byEvent[event] = delivery
+	delivery.pending++
+}
+
+// Stop tracking an event the sender did not accept, the delivery failed
+func (d *inFlightDeliveries) remove(event *models.Event) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if delivery, ok := d.byEvent[event]; ok {
+		delete(d.byEvent, event)
+		delivery.pending--
+		delivery.failed = true
+	}
+}
+
+// Mark the delivery as fully sent, return true if all its events are already confirmed
+// Otherwise it is completed along with its last event
+func (d *inFlightDeliveries) sent(delivery *inFlightDelivery) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delivery.sending = false
+	return delivery.pending == 0
+}
+
+// Record the confirmation of an event by the sender
+func (d *inFlightDeliveries) complete(event *models.Event, delivered bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delivery, ok := d.byEvent[event]
+	if !ok {
+		return
+	}
+	delete(d.byEvent, event)
+	delivery.pending--
+	if !delivered {
+		delivery.failed = true
+	}
+	if delivery.pending > 0 || delivery.sending {
+		return
+	}
+
+	d.completed = append(d.completed, delivery)
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Return and remove the deliveries completed
+func (d *inFlightDeliveries) take() []*inFlightDelivery {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	completed := d.completed
+	d.completed = nil
+	return completed
+}
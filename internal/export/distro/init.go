@@ -14,6 +14,7 @@ import (
 	"github.com/Circutor/edgex/pkg/clients"
 	"github.com/Circutor/edgex/pkg/clients/coredata"
 	"github.com/Circutor/edgex/pkg/clients/logger"
+	"github.com/Circutor/edgex/pkg/clients/metadata"
 
 	"github.com/Circutor/edgex/internal"
 	"github.com/Circutor/edgex/internal/pkg/config"
@@ -22,6 +23,7 @@ import (
 
 var LoggingClient logger.LoggingClient
 var ec coredata.EventClient
+var mdc metadata.DeviceClient
 var Configuration *ConfigurationStruct
 
 func Retry(useProfile string, timeout int, wait *sync.WaitGroup, ch chan error) {
@@ -64,12 +66,21 @@ func Init() bool {
 		return false
 	}
 
+	if Configuration.StoreAndForward.Enabled {
+		if err := openQueueStore(Configuration.StoreAndForward); err != nil {
+			LoggingClient.Error(err.Error())
+			return false
+		}
+	}
+
 	go telemetry.StartCpuUsageAverage()
 
 	return true
 }
 
 func Destruct() {
+	closeQueueStore()
+	closeDexmaSequences()
 }
 
 func initializeConfiguration(useProfile string) (*ConfigurationStruct, error) {
@@ -86,6 +97,10 @@ func initializeClient() {
 	// Create data client
 	url := Configuration.Clients["CoreData"].Url() + clients.ApiEventRoute
 	ec = coredata.NewEventClient(url)
+
+	// Create metadata client, it gives the device labels of the topics
+	url = Configuration.Clients["Metadata"].Url() + clients.ApiDeviceRoute
+	mdc = metadata.NewDeviceClient(url)
 }
 
 func setLoggingTarget() string {
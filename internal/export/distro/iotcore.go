@@ -8,12 +8,16 @@
 package distro
 
 import (
-	"crypto/tls"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/Circutor/edgex/pkg/models"
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	contract "github.com/Circutor/edgex/pkg/models"
 	jwt "github.com/dgrijalva/jwt-go"
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 )
@@ -25,11 +29,17 @@ const (
 	projectsPrefix  = "/projects/"
 	locationsPrefix = "/locations/"
 	devicesPrefix   = "/devices/"
+
+	defaultTokenLifetime = 3600000
 )
 
+// Fraction of the lifetime of the JWT left when it is refreshed
+const tokenRefreshMargin = 0.1
+
 // newIoTCoreSender returns new Google IoT Core sender instance.
-func newIoTCoreSender(addr models.Addressable) sender {
+func newIoTCoreSender(addr contract.Addressable) sender {
 	protocol := strings.ToLower(addr.Protocol)
+	addr = withLegacyKey(addr)
 	broker := fmt.Sprintf("%s%s", addr.GetBaseURL(), addr.Path)
 	deviceID := extractDeviceID(addr.Publisher)
 	projectID := extractProjectID(addr.Publisher)
@@ -41,39 +51,142 @@ func newIoTCoreSender(addr models.Addressable) sender {
 	opts.SetAutoReconnect(true)
 	opts.SetProtocolVersion(4)
 
+	var signer *jwtSigner
 	if validateProtocol(protocol) {
-		cert, err := tls.X509KeyPair([]byte(addr.Certificate), []byte(addr.Password))
+		tlsConfig, err := newTLSConfig(addr)
 		if err != nil {
-			LoggingClient.Error(fmt.Sprintf("Failed loading x509 data: %s", err.Error()))
+			LoggingClient.Error(fmt.Sprintf("Failed configuring TLS: %s", err.Error()))
 			return nil
 		}
+		if len(tlsConfig.Certificates) == 0 {
+			LoggingClient.Error("IoT Core needs a certificate and key to sign the JWT")
+			return nil
+		}
+		opts.SetTLSConfig(tlsConfig)
 
-		opts.SetTLSConfig(&tls.Config{
-			InsecureSkipVerify: true,
-			Certificates:       []tls.Certificate{cert},
-		})
-
-		now := time.Now()
-		t := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{
-			IssuedAt:  now.Unix(),
-			ExpiresAt: now.Add(time.Hour * 24).Unix(),
-			Audience:  projectID,
-		})
-		password, err := t.SignedString(cert.PrivateKey)
+		signer, err = newJWTSigner(projectID, tlsConfig.Certificates[0].PrivateKey)
 		if err != nil {
 			LoggingClient.Error(fmt.Sprintf("Could not generate JWT: %s", err.Error()))
 			return nil
 		}
-		opts.SetPassword(password)
+		// A new JWT is signed on every connection, including the automatic reconnections
+		opts.SetCredentialsProvider(func() (string, string) {
+			password, err := signer.sign()
+			if err != nil {
+				LoggingClient.Error(fmt.Sprintf("Could not generate JWT: %s", err.Error()))
+			}
+			return addr.User, password
+		})
 	}
 
+	// Subscribe again after reconnecting, the session is not kept
+	opts.SetOnConnectHandler(func(client MQTT.Client) {
+		subscribeIoTCore(client, deviceID)
+	})
+
 	if addr.Topic == "" {
 		addr.Topic = fmt.Sprintf("/devices/%s/events", deviceID)
 	}
 
-	return &mqttSender{
-		client: MQTT.NewClient(opts),
-		topic:  addr.Topic,
+	publisher := newMqttPublisher(opts, addr)
+	if publisher == nil {
+		return nil
+	}
+	if signer == nil {
+		return publisher
+	}
+	return &iotCoreSender{mqttSender: publisher, signer: signer}
+}
+
+// MQTT sender reconnecting with a new JWT before the current one expires
+type iotCoreSender struct {
+	*mqttSender
+	signer *jwtSigner
+}
+
+func (sender *iotCoreSender) Send(data []byte, event *models.Event) bool {
+	if sender.client.IsConnected() && sender.signer.expiresSoon() {
+		LoggingClient.Info("Refreshing IoT Core JWT")
+		sender.client.Disconnect(250)
+	}
+	return sender.mqttSender.Send(data, event)
+}
+
+// Signs the JWTs used as password with the key of the device, RS256 for RSA keys and ES256 for EC keys
+type jwtSigner struct {
+	mutex     sync.Mutex
+	projectID string
+	key       crypto.PrivateKey
+	method    jwt.SigningMethod
+	lifetime  time.Duration
+	expires   time.Time
+}
+
+func newJWTSigner(projectID string, key crypto.PrivateKey) (*jwtSigner, error) {
+	var method jwt.SigningMethod
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		method = jwt.SigningMethodES256
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, RSA or EC keys are needed", key)
+	}
+
+	lifetime := Configuration.IoTCore.TokenLifetime
+	if lifetime <= 0 {
+		lifetime = defaultTokenLifetime
+	}
+	return &jwtSigner{
+		projectID: projectID,
+		key:       key,
+		method:    method,
+		lifetime:  time.Duration(lifetime) * time.Millisecond,
+	}, nil
+}
+
+// Return a new JWT
+func (s *jwtSigner) sign() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	expires := now.Add(s.lifetime)
+	t := jwt.NewWithClaims(s.method, jwt.StandardClaims{
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+		Audience:  s.projectID,
+	})
+	token, err := t.SignedString(s.key)
+	if err != nil {
+		return "", err
+	}
+	s.expires = expires
+	return token, nil
+}
+
+// Return true if the last JWT is about to expire
+func (s *jwtSigner) expiresSoon() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	margin := time.Duration(float64(s.lifetime) * tokenRefreshMargin)
+	return time.Now().Add(margin).After(s.expires)
+}
+
+// Subscribe to the configuration and commands sent by IoT Core to the device
+func subscribeIoTCore(client MQTT.Client, deviceID string) {
+	onMessage := func(client MQTT.Client, msg MQTT.Message) {
+		LoggingClient.Info(fmt.Sprintf("IoT Core message on %s: %s", msg.Topic(), string(msg.Payload())))
+	}
+
+	topics := map[string]byte{
+		fmt.Sprintf("/devices/%s/config", deviceID):     1,
+		fmt.Sprintf("/devices/%s/commands/#", deviceID): 0,
+	}
+	for topic, qos := range topics {
+		if token := client.Subscribe(topic, qos, onMessage); token.Wait() && token.Error() != nil {
+			LoggingClient.Error(fmt.Sprintf("Could not subscribe to %s: %s", topic, token.Error().Error()))
+		}
 	}
 }
 
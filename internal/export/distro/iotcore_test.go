@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+func TestJWTSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating EC key: %v", err)
+	}
+
+	var tests = []struct {
+		name   string
+		key    crypto.PrivateKey
+		public interface{}
+		method string
+	}{
+		{"rsa", rsaKey, &rsaKey.PublicKey, "RS256"},
+		{"ecdsa", ecKey, &ecKey.PublicKey, "ES256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newJWTSigner("project", tt.key)
+			if err != nil {
+				t.Fatalf("Error creating signer: %v", err)
+			}
+			signed, err := signer.sign()
+			if err != nil {
+				t.Fatalf("Error signing JWT: %v", err)
+			}
+
+			claims := &jwt.StandardClaims{}
+			token, err := jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+				return tt.public, nil
+			})
+			if err != nil || !token.Valid {
+				t.Fatalf("JWT should be valid: %v", err)
+			}
+			if token.Method.Alg() != tt.method {
+				t.Errorf("JWT should be signed with %s, not %s", tt.method, token.Method.Alg())
+			}
+			if claims.Audience != "project" {
+				t.Errorf("Audience should be the project, not %s", claims.Audience)
+			}
+			if claims.ExpiresAt-claims.IssuedAt != int64(defaultTokenLifetime/1000) {
+				t.Errorf("JWT should use the default lifetime")
+			}
+		})
+	}
+}
+
+func TestJWTSignerRefresh(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	Configuration.IoTCore.TokenLifetime = 1000
+	defer func() { Configuration.IoTCore.TokenLifetime = 0 }()
+
+	signer, _ := newJWTSigner("project", key)
+	if !signer.expiresSoon() {
+		t.Errorf("A JWT should be signed before connecting")
+	}
+	signer.sign()
+	if signer.expiresSoon() {
+		t.Errorf("A new JWT should not be refreshed")
+	}
+	signer.expires = time.Now().Add(50 * time.Millisecond)
+	if !signer.expiresSoon() {
+		t.Errorf("The JWT should be refreshed within the last tenth of its lifetime")
+	}
+
+	if _, err := newJWTSigner("project", "key"); err == nil {
+		t.Errorf("Unsupported keys should fail")
+	}
+}
@@ -10,24 +10,33 @@
 package distro
 
 import (
-	"crypto/tls"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Circutor/edgex/internal/pkg/correlation/models"
 	contract "github.com/Circutor/edgex/pkg/models"
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 )
 
+// How long connecting and publishing wait for the broker
+const mqttTimeout = 10 * time.Second
+
 type mqttSender struct {
-	client MQTT.Client
-	topic  string
+	client   MQTT.Client
+	topic    string
+	template *topicTemplate // Resolves the topic of each event, nil when it is static
+	options  contract.MQTTOptions
+
+	inFlight  chan struct{}                             // Slots of the publications not confirmed yet, nil when synchronous
+	completed func(event *models.Event, delivered bool) // Called with the asynchronous publications confirmed
 }
 
 // newMqttSender - create new mqtt sender
 func newMqttSender(addr contract.Addressable) sender {
 	protocol := strings.ToLower(addr.Protocol)
+	addr = withLegacyKey(addr)
 
 	opts := MQTT.NewClientOptions()
 	broker := protocol + "://" + addr.Address + ":" + strconv.Itoa(addr.Port) + addr.Path
@@ -35,58 +44,108 @@ func newMqttSender(addr contract.Addressable) sender {
 	opts.SetClientID(addr.Publisher)
 	opts.SetUsername(addr.User)
 	opts.SetPassword(addr.Password)
+	// Reconnections are done by Send so they are retried with the events
 	opts.SetAutoReconnect(false)
+	opts.SetConnectTimeout(mqttTimeout)
 
-	if protocol == "tcps" || protocol == "ssl" || protocol == "tls" {
-		var tlsConfig *tls.Config
-		if addr.Certificate == "" {
-			tlsConfig = &tls.Config{
-				ClientCAs:          nil,
-				InsecureSkipVerify: true,
-			}
-		} else {
-			cert, err := tls.X509KeyPair([]byte(addr.Certificate), []byte(addr.Password))
-			if err != nil {
-				LoggingClient.Error(fmt.Sprintf("Failed loading x509 data: %s", err.Error()))
-				return nil
-			}
-
-			tlsConfig = &tls.Config{
-				ClientCAs:          nil,
-				InsecureSkipVerify: true,
-				Certificates:       []tls.Certificate{cert},
-			}
+	if validateProtocol(protocol) {
+		tlsConfig, err := newTLSConfig(addr)
+		if err != nil {
+			LoggingClient.Error(fmt.Sprintf("Failed configuring TLS: %s", err.Error()))
+			return nil
 		}
-
 		opts.SetTLSConfig(tlsConfig)
+	}
 
+	publisher := newMqttPublisher(opts, addr)
+	if publisher == nil {
+		return nil
 	}
+	return publisher
+}
 
-	sender := &mqttSender{
-		client: MQTT.NewClient(opts),
-		topic:  addr.Topic,
+// Create the sender applying the MQTT options and topic of the addressable
+func newMqttPublisher(opts *MQTT.ClientOptions, addr contract.Addressable) *mqttSender {
+	template, err := newTopicTemplate(addr.Topic)
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return nil
+	}
+
+	options := addr.MQTT
+	opts.SetCleanSession(!options.PersistentSession)
+	if options.KeepAlive > 0 {
+		opts.SetKeepAlive(time.Duration(options.KeepAlive) * time.Second)
+	}
+	if options.WillTopic != "" {
+		opts.SetWill(options.WillTopic, options.WillMessage, options.WillQoS, options.WillRetain)
 	}
 
+	sender := &mqttSender{
+		client:   MQTT.NewClient(opts),
+		topic:    addr.Topic,
+		template: template,
+		options:  options,
+	}
+	if options.MaxInFlight > 0 {
+		sender.inFlight = make(chan struct{}, options.MaxInFlight)
+	}
 	return sender
 }
 
+// Set the function called with the events whose asynchronous publication is confirmed
+func (sender *mqttSender) onComplete(completed func(event *models.Event, delivered bool)) bool {
+	sender.completed = completed
+	return sender.inFlight != nil
+}
+
+// Publish the data, waiting for the broker unless publishing asynchronously
+// Asynchronous publications return once they are in flight, waiting while the window is full
 func (sender *mqttSender) Send(data []byte, event *models.Event) bool {
+	topic := sender.topic
+	if sender.template != nil {
+		var err error
+		if topic, err = sender.template.resolve(event.Event); err != nil {
+			LoggingClient.Error(fmt.Sprintf("Could not resolve the topic of event %s: %s", event.ID, err.Error()))
+			return false
+		}
+	}
+
 	if !sender.client.IsConnected() {
 		LoggingClient.Info("Connecting to mqtt server")
 		if token := sender.client.Connect(); token.Wait() && token.Error() != nil {
-			LoggingClient.Error(fmt.Sprintf("Could not connect to mqtt server, drop event. Error: %s", token.Error().Error()))
+			LoggingClient.Error(fmt.Sprintf("Could not connect to mqtt server. Error: %s", token.Error().Error()))
 			return false
 		}
 	}
 
-	token := sender.client.Publish(sender.topic, 0, false, data)
-	// FIXME: could be removed? set of tokens?
-	token.Wait()
+	if sender.inFlight != nil {
+		sender.inFlight <- struct{}{}
+		token := sender.client.Publish(topic, sender.options.QoS, sender.options.Retain, data)
+		go func() {
+			delivered := waitPublished(token)
+			<-sender.inFlight
+			if sender.completed != nil {
+				sender.completed(event, delivered)
+			}
+		}()
+		return true
+	}
+
+	token := sender.client.Publish(topic, sender.options.QoS, sender.options.Retain, data)
+	return waitPublished(token)
+}
+
+// Wait until the broker confirms a publication, return false if it failed
+func waitPublished(token MQTT.Token) bool {
+	if !token.WaitTimeout(mqttTimeout) {
+		LoggingClient.Error("Timeout publishing to mqtt server")
+		return false
+	}
 	if token.Error() != nil {
 		LoggingClient.Error(token.Error().Error())
 		return false
-	} else {
-		LoggingClient.Info(fmt.Sprintf("Sent data to mqtt server"))
-		return true
 	}
+	LoggingClient.Info(fmt.Sprintf("Sent data to mqtt server"))
+	return true
 }
@@ -0,0 +1,185 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	contract "github.com/Circutor/edgex/pkg/models"
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Token completed when done is closed
+type testToken struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *testToken) Wait() bool {
+	<-t.done
+	return true
+}
+
+func (t *testToken) WaitTimeout(d time.Duration) bool {
+	select {
+	case <-t.done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+func (t *testToken) Error() error {
+	return t.err
+}
+
+// Connected client recording the publications, their tokens are completed by the test
+type testMqttClient struct {
+	MQTT.Client
+	mutex  sync.Mutex
+	qos    byte
+	retain bool
+	tokens []*testToken
+}
+
+func (c *testMqttClient) IsConnected() bool {
+	return true
+}
+
+func (c *testMqttClient) Publish(topic string, qos byte, retained bool, payload interface{}) MQTT.Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.qos = qos
+	c.retain = retained
+	token := &testToken{done: make(chan struct{})}
+	c.tokens = append(c.tokens, token)
+	return token
+}
+
+func (c *testMqttClient) token(i int) *testToken {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.tokens[i]
+}
+
+func (c *testMqttClient) published() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.tokens)
+}
+
+func newTestMqttSender(options contract.MQTTOptions) (*mqttSender, *testMqttClient) {
+	client := &testMqttClient{}
+	sender := newMqttPublisher(MQTT.NewClientOptions(), contract.Addressable{Topic: "test", MQTT: options})
+	sender.client = client
+	return sender, client
+}
+
+func TestMqttSenderOptions(t *testing.T) {
+	sender, client := newTestMqttSender(contract.MQTTOptions{QoS: 1, Retain: true})
+
+	result := make(chan bool)
+	go func() {
+		result <- sender.Send([]byte{}, &models.Event{})
+	}()
+	for client.published() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(client.token(0).done)
+
+	if !<-result {
+		t.Errorf("The publication should be sent")
+	}
+	if client.qos != 1 || !client.retain {
+		t.Errorf("The publication should use QoS 1 and be retained")
+	}
+}
+
+func TestMqttSenderAsync(t *testing.T) {
+	sender, client := newTestMqttSender(contract.MQTTOptions{MaxInFlight: 2})
+	failed := make(chan *models.Event, 1)
+	if !sender.onComplete(func(event *models.Event, delivered bool) {
+		if !delivered {
+			failed <- event
+		}
+	}) {
+		t.Fatalf("The publications should be confirmed asynchronously")
+	}
+
+	first := &models.Event{Event: contract.Event{ID: "1"}}
+	if !sender.Send([]byte{}, first) || !sender.Send([]byte{}, &models.Event{}) {
+		t.Fatalf("The publications should be in flight")
+	}
+
+	// The window is full until a publication is confirmed
+	sent := make(chan bool)
+	go func() {
+		sent <- sender.Send([]byte{}, &models.Event{})
+	}()
+	select {
+	case <-sent:
+		t.Fatalf("The publication should wait for room in the window")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	token := client.token(0)
+	token.err = errors.New("not accepted")
+	close(token.done)
+	if !<-sent {
+		t.Errorf("The publication should be in flight")
+	}
+
+	select {
+	case event := <-failed:
+		if event != first {
+			t.Errorf("The failed event should be reported")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("The failed publication should be reported")
+	}
+}
+
+func TestMqttSenderAsyncMarkPushed(t *testing.T) {
+	client := &unpushedClient{}
+	previous := ec
+	ec = client
+	Configuration.Writable.MarkPushed = true
+	defer func() {
+		ec = previous
+		Configuration.Writable.MarkPushed = false
+	}()
+
+	sender, mqtt := newTestMqttSender(contract.MQTTOptions{MaxInFlight: 2})
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "async", Enable: true}
+	reg.format = jsonFormatter{}
+	reg.sender = sender
+	reg.async = sender.onComplete(reg.inFlight.complete)
+
+	// The events are marked as pushed once the broker confirms them, the others are replayed
+	reg.processEvent(newTestEvent("1"))
+	reg.processEvent(newTestEvent("2"))
+	if len(client.pushed) != 0 {
+		t.Fatalf("The events in flight should not be marked as pushed")
+	}
+	token := mqtt.token(0)
+	token.err = errors.New("not accepted")
+	close(token.done)
+	close(mqtt.token(1).done)
+	for len(client.pushed) == 0 {
+		<-reg.inFlight.notify
+		reg.settleInFlight()
+	}
+	if len(client.pushed) != 1 || client.pushed[0] != "2" {
+		t.Errorf("Only the event confirmed should be marked as pushed: %v", client.pushed)
+	}
+}
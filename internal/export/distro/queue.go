@@ -0,0 +1,327 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	"github.com/Circutor/edgex/internal/pkg/db"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultQueueFile     = "distro-queue.db"
+	defaultRetryInterval = 30000
+	queueReadBatch       = 100
+	queueOpenTimeout     = time.Second
+)
+
+// Store of the persistent queues, nil when store-and-forward is disabled
+var queueDB *bolt.DB
+
+// Persistent queue of the events waiting to be sent by a registration
+// Events are kept in arrival order, one bucket per registration
+type eventQueue struct {
+	bucket []byte
+	count  int64 // Events in the queue, counted when it is created and accessed atomically
+
+	// Forwarding state, only used from the loop of the registration
+	next []byte          // Key of the last entry forwarded, forward goes on after it
+	held map[string]bool // Entries of the events aggregated or batched, kept until they are delivered
+}
+
+// Event stored in a queue
+type queuedEvent struct {
+	Enqueued int64 // When the event was added to the queue
+	Event    models.Event
+}
+
+// Entry read from a queue, the key acknowledges it
+// The event is nil when it could not be decoded
+type queueEntry struct {
+	key   []byte
+	event *models.Event
+}
+
+// Open the store of the persistent queues
+func openQueueStore(info StoreAndForwardInfo) error {
+	file := info.File
+	if file == "" {
+		file = defaultQueueFile
+	}
+
+	var err error
+	queueDB, err = bolt.Open(file, 0600, &bolt.Options{Timeout: queueOpenTimeout})
+	if err != nil {
+		queueDB = nil
+		return fmt.Errorf("couldn't open the store-and-forward queue %s: %v", file, err)
+	}
+	return nil
+}
+
+func closeQueueStore() {
+	if queueDB != nil {
+		queueDB.Close()
+		queueDB = nil
+	}
+}
+
+func newEventQueue(name string) *eventQueue {
+	q := &eventQueue{bucket: []byte(name), held: make(map[string]bool)}
+	queueDB.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(q.bucket); b != nil {
+			q.count = int64(b.Stats().KeyN)
+		}
+		return nil
+	})
+	return q
+}
+
+// Append an event at the end of the queue
+func (q *eventQueue) push(event *models.Event) error {
+	encoded, err := json.Marshal(queuedEvent{Enqueued: db.MakeTimestamp(), Event: *event})
+	if err != nil {
+		return err
+	}
+
+	err = queueDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(q.bucket)
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		return b.Put(key, encoded)
+	})
+	if err == nil {
+		atomic.AddInt64(&q.count, 1)
+	}
+	return err
+}
+
+// Return up to limit events from the front of the queue, or following the after key if it is not nil
+func (q *eventQueue) peek(after []byte, limit int) ([]queueEntry, error) {
+	var entries []queueEntry
+	err := queueDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		k, v := c.First()
+		if after != nil {
+			if k, v = c.Seek(after); bytes.Equal(k, after) {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && len(entries) < limit; k, v = c.Next() {
+			// Keys are only valid during the transaction
+			entry := queueEntry{key: append([]byte{}, k...)}
+			var stored queuedEvent
+			if err := json.Unmarshal(v, &stored); err == nil {
+				entry.event = &stored.Event
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Remove a sent event from the queue
+func (q *eventQueue) ack(key []byte) error {
+	return q.remove([][]byte{key})
+}
+
+// Remove events from the queue, keeping the count of those left
+func (q *eventQueue) remove(keys [][]byte) error {
+	removed := 0
+	err := queueDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+		if b == nil {
+			return nil
+		}
+		for _, key := range keys {
+			if b.Get(key) == nil {
+				continue
+			}
+			if err := b.Delete(key); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err == nil {
+		atomic.AddInt64(&q.count, -int64(removed))
+	}
+	return err
+}
+
+// Keep an entry in the queue until the events aggregated or batched with it are delivered
+func (q *eventQueue) hold(key []byte) {
+	q.held[string(key)] = true
+}
+
+// Release the entries held, they are acknowledged if delivered or forwarded again otherwise
+// The keys not held are ignored, forward acknowledges them
+func (q *eventQueue) release(keys [][]byte, delivered bool) error {
+	var released [][]byte
+	for _, key := range keys {
+		if q.held[string(key)] {
+			delete(q.held, string(key))
+			released = append(released, key)
+		}
+	}
+	if len(released) == 0 {
+		return nil
+	}
+	if !delivered {
+		q.next = nil
+		return nil
+	}
+	return q.remove(released)
+}
+
+// Remove the oldest events so there are less than maxEvents and none older than maxAge milliseconds
+// A zero limit is not enforced. Return the number of events removed
+func (q *eventQueue) trim(maxEvents int, maxAge int64) (int, error) {
+	if maxEvents <= 0 && maxAge <= 0 {
+		return 0, nil
+	}
+
+	count := q.length()
+	removed := 0
+	err := queueDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(q.bucket)
+		if b == nil {
+			return nil
+		}
+
+		oldest := db.MakeTimestamp() - maxAge
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			full := maxEvents > 0 && count >= maxEvents
+			expired := false
+			if maxAge > 0 {
+				var stored queuedEvent
+				expired = json.Unmarshal(v, &stored) != nil || stored.Enqueued < oldest
+			}
+			if !full && !expired {
+				break
+			}
+
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			count--
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&q.count, -int64(removed))
+	return removed, nil
+}
+
+// Number of events in the queue
+func (q *eventQueue) length() int {
+	return int(atomic.LoadInt64(&q.count))
+}
+
+// Remove the queue with all its events
+func (q *eventQueue) drop() error {
+	err := queueDB.Update(func(tx *bolt.Tx) error {
+		err := tx.DeleteBucket(q.bucket)
+		if err == bolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+	if err == nil {
+		atomic.StoreInt64(&q.count, 0)
+	}
+	return err
+}
+
+// Store an event in the registration queue, making room for it if needed
+func (reg *registrationInfo) store(event *models.Event) error {
+	info := Configuration.StoreAndForward
+	removed, err := reg.queue.trim(info.MaxEvents, info.MaxAge)
+	if err != nil {
+		return err
+	}
+	if removed > 0 {
+		atomic.AddUint64(&reg.counters.dropped, uint64(removed))
+		LoggingClient.Warn(fmt.Sprintf("Queue of registration %s full, %d events dropped", reg.registration.Name, removed))
+	}
+	return reg.queue.push(event)
+}
+
+// Move the events waiting in the buffer to the queue so they are not dropped while forwarding
+func (reg *registrationInfo) storeBuffered() {
+	for {
+		select {
+		case event := <-reg.chEvent:
+			if err := reg.store(event); err != nil {
+				LoggingClient.Error(fmt.Sprintf("Failed queuing event for registration %s: %s", reg.registration.Name, err.Error()))
+				reg.processEvent(event)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Send the queued events in order, stopping at the first one the destination does not accept
+// The events aggregated or batched stay in the queue until they are delivered
+func (reg *registrationInfo) forward() {
+	for {
+		entries, err := reg.queue.peek(reg.queue.next, queueReadBatch)
+		if err != nil {
+			LoggingClient.Error(fmt.Sprintf("Failed reading queue of registration %s: %s", reg.registration.Name, err.Error()))
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+
+		for _, entry := range entries {
+			if reg.queue.held[string(entry.key)] {
+				reg.queue.next = entry.key
+				continue
+			}
+			if entry.event == nil {
+				LoggingClient.Error(fmt.Sprintf("Dropping undecodable event from queue of registration %s", reg.registration.Name))
+			} else if !reg.process(entry.event, entry.key) {
+				return
+			}
+			if !reg.queue.held[string(entry.key)] {
+				if err := reg.queue.ack(entry.key); err != nil {
+					LoggingClient.Error(fmt.Sprintf("Failed acknowledging event of registration %s: %s", reg.registration.Name, err.Error()))
+					return
+				}
+			}
+			reg.queue.next = entry.key
+			reg.storeBuffered()
+		}
+	}
+}
@@ -0,0 +1,261 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	"github.com/Circutor/edgex/pkg/clients/coredata"
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Sender that accepts the events only when it is up
+type flakySender struct {
+	up   bool
+	sent []string
+}
+
+func (sender *flakySender) Send(data []byte, event *models.Event) bool {
+	if sender.up {
+		sender.sent = append(sender.sent, event.ID)
+	}
+	return sender.up
+}
+
+func openTestQueueStore(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "distro")
+	if err != nil {
+		t.Fatalf("Error creating temp dir: %v", err)
+	}
+	err = openQueueStore(StoreAndForwardInfo{File: filepath.Join(dir, "queue.db")})
+	if err != nil {
+		t.Fatalf("Error opening the queue: %v", err)
+	}
+	return func() {
+		closeQueueStore()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	q := newEventQueue("test")
+	for _, id := range []string{"1", "2", "3"} {
+		if err := q.push(newTestEvent(id)); err != nil {
+			t.Fatalf("Error pushing event: %v", err)
+		}
+	}
+
+	entries, err := q.peek(nil, 2)
+	if err != nil {
+		t.Fatalf("Error reading the queue: %v", err)
+	}
+	if len(entries) != 2 || entries[0].event.ID != "1" || entries[1].event.ID != "2" {
+		t.Fatalf("The oldest events should be read first")
+	}
+
+	if err = q.ack(entries[0].key); err != nil {
+		t.Fatalf("Error acknowledging event: %v", err)
+	}
+	if q.length() != 2 {
+		t.Errorf("There should be 2 events queued, not %d", q.length())
+	}
+
+	removed, err := q.trim(2, 0)
+	if err != nil {
+		t.Fatalf("Error trimming the queue: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("There should be 1 event removed, not %d", removed)
+	}
+	entries, _ = q.peek(nil, 10)
+	if len(entries) != 1 || entries[0].event.ID != "3" {
+		t.Errorf("Only the newest event should be kept")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	removed, _ = q.trim(0, 1)
+	if removed != 1 || q.length() != 0 {
+		t.Errorf("The expired event should be removed")
+	}
+
+	if err = q.drop(); err != nil {
+		t.Errorf("Error dropping the queue: %v", err)
+	}
+}
+
+func TestForwardAfterOutage(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	sender := &flakySender{}
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "outage", Enable: true}
+	reg.format = jsonFormatter{}
+	reg.sender = sender
+	reg.queue = newEventQueue(reg.registration.Name)
+
+	for _, id := range []string{"1", "2"} {
+		if err := reg.store(newTestEvent(id)); err != nil {
+			t.Fatalf("Error storing event: %v", err)
+		}
+		reg.forward()
+	}
+	if len(sender.sent) != 0 || reg.queue.length() != 2 {
+		t.Fatalf("The events should be kept while the destination is down")
+	}
+
+	sender.up = true
+	reg.chEvent <- newTestEvent("3")
+	reg.forward()
+
+	if len(sender.sent) != 3 || sender.sent[0] != "1" || sender.sent[1] != "2" || sender.sent[2] != "3" {
+		t.Errorf("The events should be sent in order, sent %v", sender.sent)
+	}
+	if reg.queue.length() != 0 {
+		t.Errorf("The queue should be empty, not %d", reg.queue.length())
+	}
+	if reg.counters.failed != 2 || reg.counters.sent != 3 {
+		t.Errorf("Unexpected counters %+v", *reg.counters)
+	}
+}
+
+func TestForwardAsyncMqtt(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	sender, client := newTestMqttSender(contract.MQTTOptions{MaxInFlight: 2})
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "async", Enable: true}
+	reg.format = jsonFormatter{}
+	reg.sender = newRetrySender(reg.registration.Name, sender, reg.breaker)
+	reg.async = reg.sender.(asyncSender).onComplete(reg.inFlight.complete)
+	reg.queue = newEventQueue(reg.registration.Name)
+
+	if err := reg.store(newTestEvent("1")); err != nil {
+		t.Fatalf("Error storing event: %v", err)
+	}
+	reg.forward()
+	if client.published() != 1 || reg.queue.length() != 1 {
+		t.Fatalf("The event should be kept queued while in flight")
+	}
+
+	// The event the broker did not accept is forwarded again from the queue
+	token := client.token(0)
+	token.err = errors.New("not accepted")
+	close(token.done)
+	<-reg.inFlight.notify
+	reg.settleInFlight()
+	reg.forward()
+	if client.published() != 2 || reg.queue.length() != 1 || reg.counters.failed != 1 {
+		t.Fatalf("The failed event should be sent again, %d published", client.published())
+	}
+
+	close(client.token(1).done)
+	<-reg.inFlight.notify
+	reg.settleInFlight()
+	if reg.queue.length() != 0 || reg.counters.sent != 1 {
+		t.Errorf("The event confirmed should be acknowledged, %d queued", reg.queue.length())
+	}
+}
+
+func TestEventQueueCount(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	q := newEventQueue("count")
+	for _, id := range []string{"1", "2", "3"} {
+		q.push(newTestEvent(id))
+	}
+	entries, _ := q.peek(nil, 1)
+	q.ack(entries[0].key)
+	q.ack(entries[0].key)
+
+	// The count is kept in memory and taken from the store when the queue is opened again
+	if q.length() != 2 || newEventQueue("count").length() != 2 {
+		t.Errorf("There should be 2 events queued, not %d", q.length())
+	}
+}
+
+// Core data client serving the events not pushed by a registration
+type unpushedClient struct {
+	coredata.EventClient
+	events []contract.Event
+	pushed []string
+}
+
+func (client *unpushedClient) EventsUnpushedForRegistration(registration string, nUnpushed int, ctx context.Context) ([]contract.Event, error) {
+	return client.events, nil
+}
+
+func (client *unpushedClient) MarkPushedForRegistration(id string, registration string, ctx context.Context) error {
+	client.pushed = append(client.pushed, id)
+	return nil
+}
+
+func TestReplayUnpushedQueued(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	client := &unpushedClient{events: []contract.Event{{ID: "1"}}}
+	previous := ec
+	ec = client
+	Configuration.Writable.MarkPushed = true
+	defer func() {
+		ec = previous
+		Configuration.Writable.MarkPushed = false
+	}()
+
+	sender := &flakySender{up: true}
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "replay", Enable: true}
+	reg.format = jsonFormatter{}
+	reg.sender = sender
+
+	reg.replayUnpushed()
+	if len(sender.sent) != 1 || len(client.pushed) != 1 {
+		t.Fatalf("The unpushed event should be sent again, sent %v", sender.sent)
+	}
+
+	// The events still queued must only be sent by forward, once and in order
+	reg.queue = newEventQueue(reg.registration.Name)
+	reg.replayUnpushed()
+	if len(sender.sent) != 1 {
+		t.Errorf("The unpushed events should not be replayed with store-and-forward, sent %v", sender.sent)
+	}
+}
+
+func TestForwardDeadband(t *testing.T) {
+	defer openTestQueueStore(t)()
+
+	sender := &flakySender{}
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "deadband", Enable: true}
+	reg.format = jsonFormatter{}
+	reg.sender = sender
+	reg.filter = []filterer{newDeadbandFilter(contract.Filter{Deadbands: []contract.Deadband{{Absolute: 1}}})}
+	reg.queue = newEventQueue(reg.registration.Name)
+
+	event := newTestEvent("1")
+	event.Readings = []contract.Reading{{Name: "POWER", Value: "10"}}
+	if err := reg.store(event); err != nil {
+		t.Fatalf("Error storing event: %v", err)
+	}
+	reg.forward()
+
+	// The reading that failed to be delivered is not taken as the last one reported
+	sender.up = true
+	reg.forward()
+	if len(sender.sent) != 1 || reg.queue.length() != 0 {
+		t.Errorf("The event should be sent once the destination is up, sent %v", sender.sent)
+	}
+}
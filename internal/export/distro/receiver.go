@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"fmt"
+
+	"github.com/Circutor/edgex/internal/pkg/bus"
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+)
+
+const mqttReceiverClientPrefix = "edgex-export-distro-"
+
+// Start the receiver of the configured message bus type
+// The all-in-one binary reads the in-process channel itself, no receiver is started for it
+func StartReceiver(eventCh chan *models.Event) error {
+	switch Configuration.MessageQueue.Type {
+	case "", bus.ZeroMQ, bus.Mangos:
+		MangosReceiver(eventCh)
+	case bus.Channel:
+		return fmt.Errorf("message queue type '%s' is only supported by the all-in-one binary", bus.Channel)
+	case bus.MQTT:
+		return MqttReceiver(eventCh)
+	default:
+		return fmt.Errorf("message queue type '%s' not supported", Configuration.MessageQueue.Type)
+	}
+	return nil
+}
+
+// Receive the events published on the MQTT broker
+func MqttReceiver(eventCh chan *models.Event) error {
+	topic := Configuration.MessageQueue.Topic
+	if topic == "" {
+		topic = bus.DefaultTopic
+	}
+
+	onMessage := func(client MQTT.Client, msg MQTT.Message) {
+		str := string(msg.Payload())
+		event := parseEvent(str)
+		if event == nil {
+			return
+		}
+		LoggingClient.Debug(fmt.Sprintf("Event received: %s", str))
+		eventCh <- event
+	}
+
+	opts := MQTT.NewClientOptions()
+	opts.AddBroker(Configuration.MessageQueue.Uri())
+	opts.SetClientID(mqttReceiverClientPrefix + uuid.New().String())
+	opts.SetAutoReconnect(true)
+	// Subscribe again after reconnecting, the session is not kept
+	opts.SetOnConnectHandler(func(client MQTT.Client) {
+		if token := client.Subscribe(topic, 0, onMessage); token.Wait() && token.Error() != nil {
+			LoggingClient.Error(fmt.Sprintf("Could not subscribe to %s: %s", topic, token.Error().Error()))
+		}
+	})
+
+	LoggingClient.Info("Connecting to incoming mqtt at: " + Configuration.MessageQueue.Uri())
+	client := MQTT.NewClient(opts)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("could not connect to mqtt server: %s", token.Error().Error())
+	}
+	LoggingClient.Info("Connected to mqtt, subscribed to " + topic)
+
+	return nil
+}
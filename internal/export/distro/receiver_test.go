@@ -0,0 +1,36 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"testing"
+
+	"github.com/Circutor/edgex/internal/pkg/bus"
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+func TestStartReceiverUnsupported(t *testing.T) {
+	Configuration.MessageQueue.Type = "unknown"
+	defer func() { Configuration.MessageQueue.Type = "" }()
+
+	err := StartReceiver(make(chan *models.Event))
+	if err == nil {
+		t.Errorf("Message queue type unknown should not be supported")
+	}
+}
+
+func TestStartReceiverChannel(t *testing.T) {
+	Configuration.MessageQueue.Type = bus.Channel
+	defer func() { Configuration.MessageQueue.Type = "" }()
+
+	// Nothing publishes on the in-process channel outside the all-in-one binary
+	err := StartReceiver(make(chan *models.Event))
+	if err == nil {
+		t.Errorf("The in-process channel should not be received by export-distro alone")
+	}
+}
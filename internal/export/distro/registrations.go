@@ -10,14 +10,11 @@
 
 package distro
 
-// TODO:
-// - Event buffer management per sender(do not block distro.Loop on full
-//   registration channel)
-
 import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Circutor/edgex/internal/pkg/correlation/models"
@@ -29,6 +26,7 @@ const (
 	awsMQTTPort         int           = 8883
 	awsThingUpdateTopic string        = "$aws/things/%s/shadow/update"
 	pushEventsTimer     time.Duration = 300
+	flushTimer          time.Duration = 1 // Checks of the aggregation windows and batches
 )
 
 var registrationChanges chan contract.NotifyUpdate = make(chan contract.NotifyUpdate, 2)
@@ -42,10 +40,24 @@ type registrationInfo struct {
 	sender       sender
 	filter       []filterer
 
+	async    bool                // The sender confirms the events after Send returns
+	inFlight *inFlightDeliveries // Deliveries the sender has not confirmed yet
+
+	splitReadings bool        // Send each reading as an event
+	aggregator    *aggregator // Nil when the readings are not aggregated
+	batch         *eventBatch // Nil when the events are sent one by one
+
 	chRegistration chan *contract.Registration
 	chEvent        chan *models.Event
-
-	deleteFlag bool
+	counters       *eventCounters
+	breaker        *circuitBreaker
+	queue          *eventQueue // Persistent queue, nil when store-and-forward is disabled
+
+	// Shared with the loop queuing the events of every registration
+	mutex      *sync.Mutex
+	regName    string        // Name of the registration, for the logs of that loop
+	deleteFlag bool          // The goroutine terminated after an invalid update
+	done       chan struct{} // Closed when deleteFlag is set
 }
 
 func RefreshRegistrations(update contract.NotifyUpdate) {
@@ -57,12 +69,62 @@ func newRegistrationInfo() *registrationInfo {
 	reg := &registrationInfo{}
 
 	reg.chRegistration = make(chan *contract.Registration)
-	reg.chEvent = make(chan *models.Event)
+	reg.chEvent = newEventBuffer()
+	reg.counters = &eventCounters{}
+	reg.breaker = newCircuitBreaker()
+	reg.inFlight = newInFlightDeliveries()
+	reg.mutex = &sync.Mutex{}
+	reg.done = make(chan struct{})
 	return reg
 }
 
+// Name of the registration, it can be called from any goroutine
+func (reg *registrationInfo) name() string {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	return reg.regName
+}
+
+// Return true if the registration goroutine terminated after an invalid update
+func (reg *registrationInfo) deleted() bool {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	return reg.deleteFlag
+}
+
+func (reg *registrationInfo) setDeleted() {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if !reg.deleteFlag {
+		reg.deleteFlag = true
+		close(reg.done)
+	}
+}
+
+// Pass an update to the registration goroutine, nil terminates it
+// Return false if the goroutine has already terminated
+func (reg *registrationInfo) notify(newReg *contract.Registration) bool {
+	select {
+	case reg.chRegistration <- newReg:
+		return true
+	case <-reg.done:
+		return false
+	}
+}
+
 func (reg *registrationInfo) update(newReg contract.Registration) bool {
+	// The readings aggregated and batched with the previous options are sent first,
+	// those not sent are replayed
+	reg.flushAggregates(time.Time{})
+	if !reg.flushBatch(time.Time{}) {
+		_, ids, keys := reg.batch.take()
+		reg.settle(ids, keys, false)
+	}
+
 	reg.registration = newReg
+	reg.mutex.Lock()
+	reg.regName = newReg.Name
+	reg.mutex.Unlock()
 
 	reg.format = nil
 	switch newReg.Format {
@@ -79,13 +141,30 @@ func (reg *registrationInfo) update(newReg contract.Registration) bool {
 	case contract.FormatAWSJSON:
 		reg.format = awsFormatter{}
 	case contract.FormatCSV:
-		// TODO reg.format = distro.NewCsvFormat()
+		reg.format = newCSVFormatter(newReg.CSV)
 	case contract.FormatThingsBoardJSON:
 		reg.format = thingsboardJSONFormatter{}
 	case "DEXMA_JSON":
-		reg.format = dexmaJSONFormatter{}
+		parameters, err := dexmaParameters(newReg.DexmaParameters)
+		if err != nil {
+			LoggingClient.Warn(fmt.Sprintf("Dexma parameters of registration %s invalid: %s", newReg.Name, err.Error()))
+			return false
+		}
+		format, err := newDexmaJSONFormatter(newReg.Name, parameters)
+		if err != nil {
+			LoggingClient.Warn(fmt.Sprintf("Dexma registration %s invalid: %s", newReg.Name, err.Error()))
+			return false
+		}
+		reg.format = format
 	case contract.FormatNOOP:
 		reg.format = noopFormatter{}
+	case contract.FormatTemplate:
+		format, err := newTemplateFormatter(newReg.Template)
+		if err != nil {
+			LoggingClient.Warn(fmt.Sprintf("Template of registration %s invalid: %s", newReg.Name, err.Error()))
+			return false
+		}
+		reg.format = format
 	default:
 		LoggingClient.Warn(fmt.Sprintf("Format not supported: %s", newReg.Format))
 		return false
@@ -107,21 +186,25 @@ func (reg *registrationInfo) update(newReg contract.Registration) bool {
 	}
 
 	reg.sender = nil
+	reg.splitReadings = false
 	switch newReg.Destination {
 	case contract.DestMQTT, contract.DestAzureMQTT:
 		reg.sender = newMqttSender(newReg.Addressable)
+		reg.splitReadings = splitsReadings(newReg.Addressable)
 	case contract.DestAWSMQTT:
 		newReg.Addressable.Protocol = "tls"
 		newReg.Addressable.Path = ""
 		newReg.Addressable.Topic = fmt.Sprintf(awsThingUpdateTopic, newReg.Addressable.Topic)
 		newReg.Addressable.Port = awsMQTTPort
 		reg.sender = newMqttSender(newReg.Addressable)
+		reg.splitReadings = splitsReadings(newReg.Addressable)
 	case contract.DestIotCoreMQTT:
 		reg.sender = newIoTCoreSender(newReg.Addressable)
+		reg.splitReadings = splitsReadings(newReg.Addressable)
 	case contract.DestRest:
-		reg.sender = newHTTPSender(newReg.Addressable)
+		reg.sender = newHTTPSender(newReg.Addressable, newHTTPContent(newReg))
 	case "DEXMA_TOPIC":
-		reg.sender = newHTTPDexmaSender(newReg.Addressable)
+		reg.sender = newHTTPDexmaSender(newReg.Addressable, newHTTPContent(newReg))
 	case contract.DestXMPP:
 		reg.sender = newXMPPSender(newReg.Addressable)
 
@@ -133,6 +216,11 @@ func (reg *registrationInfo) update(newReg contract.Registration) bool {
 	if reg.sender == nil {
 		return false
 	}
+	reg.sender = newRetrySender(newReg.Name, reg.sender, reg.breaker)
+	reg.async = false
+	if async, ok := reg.sender.(asyncSender); ok {
+		reg.async = async.onComplete(reg.inFlight.complete)
+	}
 
 	reg.encrypt = nil
 	switch newReg.Encryption.Algo {
@@ -142,11 +230,17 @@ func (reg *registrationInfo) update(newReg contract.Registration) bool {
 		reg.encrypt = nil
 	case contract.EncAes:
 		reg.encrypt = newAESEncryption(newReg.Encryption)
+	case contract.EncAesGcm:
+		reg.encrypt = newAESGCMEncryption(newReg.Encryption)
 	default:
 		LoggingClient.Warn(fmt.Sprintf("Encryption not supported: %s", newReg.Encryption.Algo))
 		return false
 	}
 
+	if reg.queue == nil && queueDB != nil {
+		reg.queue = newEventQueue(newReg.Name)
+	}
+
 	reg.filter = nil
 
 	if len(newReg.Filter.DeviceIDs) > 0 {
@@ -159,10 +253,80 @@ func (reg *registrationInfo) update(newReg contract.Registration) bool {
 		LoggingClient.Debug(fmt.Sprintf("Value descriptor filter added: %s", newReg.Filter.ValueDescriptorIDs))
 	}
 
+	if newReg.Filter.DevicePattern != "" {
+		filter, err := newDevicePatternFilter(newReg.Filter)
+		if err != nil {
+			LoggingClient.Warn(fmt.Sprintf("Device pattern of registration %s invalid: %s", newReg.Name, err.Error()))
+			return false
+		}
+		reg.filter = append(reg.filter, filter)
+		LoggingClient.Debug(fmt.Sprintf("Device pattern filter added: %s", newReg.Filter.DevicePattern))
+	}
+
+	if len(newReg.Filter.DeviceLabels) > 0 {
+		reg.filter = append(reg.filter, newDeviceLabelFilter(newReg.Filter))
+		LoggingClient.Debug(fmt.Sprintf("Device label filter added: %s", newReg.Filter.DeviceLabels))
+	}
+
+	if newReg.Filter.ReadingPattern != "" {
+		filter, err := newReadingPatternFilter(newReg.Filter)
+		if err != nil {
+			LoggingClient.Warn(fmt.Sprintf("Reading pattern of registration %s invalid: %s", newReg.Name, err.Error()))
+			return false
+		}
+		reg.filter = append(reg.filter, filter)
+		LoggingClient.Debug(fmt.Sprintf("Reading pattern filter added: %s", newReg.Filter.ReadingPattern))
+	}
+
+	if len(newReg.Filter.Values) > 0 {
+		reg.filter = append(reg.filter, newValueFilter(newReg.Filter))
+		LoggingClient.Debug(fmt.Sprintf("Value filter added: %v", newReg.Filter.Values))
+	}
+
+	// The last one so only the readings exported count as reported
+	if len(newReg.Filter.Deadbands) > 0 {
+		reg.filter = append(reg.filter, newDeadbandFilter(newReg.Filter))
+		LoggingClient.Debug(fmt.Sprintf("Deadband filter added: %v", newReg.Filter.Deadbands))
+	}
+
+	reg.aggregator = nil
+	if newReg.Aggregation.Window != "" {
+		aggregator, err := newAggregator(newReg.Aggregation)
+		if err != nil {
+			LoggingClient.Warn(fmt.Sprintf("Aggregation window of registration %s invalid: %s", newReg.Name, err.Error()))
+			return false
+		}
+		reg.aggregator = aggregator
+		LoggingClient.Debug(fmt.Sprintf("Aggregation added: %s", newReg.Aggregation.Window))
+	}
+
+	reg.batch = nil
+	if newReg.Batch.IsEnabled() {
+		if _, ok := reg.format.(batchFormatter); !ok || reg.splitReadings {
+			LoggingClient.Warn(fmt.Sprintf("Format %s of registration %s can not be batched, events sent one by one", newReg.Format, newReg.Name))
+		} else {
+			batch, err := newEventBatch(newReg.Batch)
+			if err != nil {
+				LoggingClient.Warn(fmt.Sprintf("Batch of registration %s invalid: %s", newReg.Name, err.Error()))
+				return false
+			}
+			reg.batch = batch
+			LoggingClient.Debug(fmt.Sprintf("Batch added: %+v", newReg.Batch))
+		}
+	}
+
 	return true
 }
 
-func (reg registrationInfo) processEvent(event *models.Event) {
+// Filter, format and send an event
+// Return false only when the destination did not accept it
+func (reg registrationInfo) processEvent(event *models.Event) bool {
+	return reg.process(event, nil)
+}
+
+// Process an event, the queue key is nil if it was not queued
+// The queue entries of the events aggregated are held until their window is delivered
+func (reg registrationInfo) process(event *models.Event, key []byte) bool {
 	// Valid Event Filter, needed?
 
 	data := event.ToContract()
@@ -170,17 +334,229 @@ func (reg registrationInfo) processEvent(event *models.Event) {
 		var accepted bool
 		accepted, data = f.Filter(data)
 		if !accepted {
+			// Filtered events are handled, replaying them would hold back the unpushed events
 			LoggingClient.Info("Event filtered")
-			return
+			reg.markPushed([]string{event.ID})
+			return true
 		}
 	}
 
 	if reg.format == nil {
 		LoggingClient.Warn("registrationInfo with nil format")
+		return true
+	}
+
+	if reg.aggregator != nil {
+		if reg.aggregator.add(event.ID, key, data, time.Now()) && key != nil {
+			reg.queue.hold(key)
+		}
+		LoggingClient.Debug(fmt.Sprintf("Event aggregated with registration: %s", reg.registration.Name))
+		return true
+	}
+
+	var keys [][]byte
+	if key != nil {
+		keys = [][]byte{key}
+	}
+	return reg.deliver(data, event, []string{event.ID}, keys)
+}
+
+// Send an event, or add it to the batch, and settle the events it comes from once sent
+// An event does not get in a batch that could not be sent to make room for it
+func (reg registrationInfo) deliver(data *contract.Event, event *models.Event, ids []string, keys [][]byte) bool {
+	if reg.batch != nil {
+		size := 0
+		if sized, ok := reg.format.(sizedFormatter); ok && reg.batch.maxBytes > 0 {
+			size = sized.size(data)
+		} else if reg.batch.maxBytes > 0 {
+			size = len(reg.format.Format(data))
+		}
+		if (reg.batch.full() || reg.batch.exceeds(size)) && !reg.flushBatch(time.Time{}) {
+			reg.settle(ids, keys, false)
+			return false
+		}
+		reg.holdAll(keys)
+		reg.batch.add(data, ids, keys, size, time.Now())
+		if reg.batch.full() {
+			reg.flushBatch(time.Time{})
+		}
+		return true
+	}
+
+	var delivery *inFlightDelivery
+	if reg.async {
+		delivery = newInFlightDelivery(ids, keys, 1)
+	}
+
+	var sent bool
+	var formatted []*contract.Event
+	if !reg.splitReadings {
+		payload := reg.payload(data)
+		if payload == nil {
+			reg.countSent(false)
+			reg.discard(ids, keys)
+			return true
+		}
+		formatted = append(formatted, data)
+		if delivery != nil {
+			delivery.formatted = formatted
+		}
+		sent = reg.sendTracked(delivery, payload, event)
+	} else {
+		sent = true
+		for _, reading := range data.Readings {
+			part := *data
+			part.ID = event.ID
+			part.Readings = []contract.Reading{reading}
+			payload := reg.payload(&part)
+			if payload == nil {
+				continue
+			}
+			formatted = append(formatted, &part)
+			if delivery != nil {
+				delivery.formatted = formatted
+			}
+			if !reg.sendTracked(delivery, payload, &models.Event{CorrelationId: event.CorrelationId, Event: part}) {
+				sent = false
+				break
+			}
+		}
+	}
+
+	// The events in flight are settled once the sender confirms them
+	if delivery != nil && !reg.inFlight.sent(delivery) {
+		reg.holdAll(keys)
+		LoggingClient.Debug(fmt.Sprintf("Sending event with registration: %s", reg.registration.Name))
+		return sent
+	}
+
+	reg.countSent(sent)
+	reg.settle(ids, keys, sent)
+	if sent {
+		reg.settleFormatted(formatted)
+	}
+
+	LoggingClient.Debug(fmt.Sprintf("Sent event with registration: %s", reg.registration.Name))
+	return sent
+}
+
+// Send a payload tracking its event for the delivery if it is not nil
+func (reg registrationInfo) sendTracked(delivery *inFlightDelivery, payload []byte, event *models.Event) bool {
+	if delivery == nil {
+		return reg.sender.Send(payload, event)
+	}
+	reg.inFlight.add(delivery, event)
+	if !reg.sender.Send(payload, event) {
+		reg.inFlight.remove(event)
+		return false
+	}
+	return true
+}
+
+// Keep the queue entries of the events until they are settled
+func (reg registrationInfo) holdAll(keys [][]byte) {
+	if reg.queue == nil {
+		return
+	}
+	for _, key := range keys {
+		reg.queue.hold(key)
+	}
+}
+
+// Settle the deliveries the sender has confirmed
+func (reg registrationInfo) settleInFlight() {
+	for _, delivery := range reg.inFlight.take() {
+		for i := 0; i < delivery.count; i++ {
+			reg.countSent(!delivery.failed)
+		}
+		reg.settle(delivery.ids, delivery.keys, !delivery.failed)
+		if !delivery.failed {
+			reg.settleFormatted(delivery.formatted)
+		}
+	}
+}
+
+// Let the formatter forget the events delivered, like the sequence numbers assigned to them
+// The events not delivered keep them to be formatted again the same way
+func (reg registrationInfo) settleFormatted(events []*contract.Event) {
+	if settled, ok := reg.format.(settledFormatter); ok && len(events) > 0 {
+		settled.settle(events)
+	}
+}
+
+func (reg registrationInfo) markPushed(ids []string) {
+	if !Configuration.Writable.MarkPushed {
+		return
+	}
+	for _, id := range ids {
+		err := ec.MarkPushedForRegistration(id, reg.registration.Name, context.Background())
+		if err != nil {
+			LoggingClient.Error(fmt.Sprintf("Failed to mark event as pushed by %s : event ID = %s: %s", reg.registration.Name, id, err))
+		}
+	}
+}
+
+// Settle the events sent, or failed to be sent, aggregated or batched
+// Those delivered are marked as pushed and the queue entries held for them acknowledged,
+// the others are forwarded again from the queue or replayed with the unpushed events
+func (reg registrationInfo) settle(ids []string, keys [][]byte, delivered bool) {
+	reg.settleHandled(ids, keys, delivered, delivered)
+}
+
+// Settle the events that can not be delivered, like those whose format fails
+// They are handled like the filtered events so they are not sent again
+func (reg registrationInfo) discard(ids []string, keys [][]byte) {
+	reg.settleHandled(ids, keys, false, true)
+}
+
+// Settle the events, those handled are not sent again whether they were delivered or not
+func (reg registrationInfo) settleHandled(ids []string, keys [][]byte, delivered bool, handled bool) {
+	if handled {
+		reg.markPushed(ids)
+	}
+	for _, f := range reg.filter {
+		if settled, ok := f.(settledFilterer); ok {
+			settled.settle(ids, delivered)
+		}
+	}
+	if reg.aggregator != nil {
+		reg.aggregator.done(ids)
+	}
+	if reg.queue == nil || len(keys) == 0 {
+		return
+	}
+	if err := reg.queue.release(keys, handled); err != nil {
+		LoggingClient.Error(fmt.Sprintf("Failed acknowledging events of registration %s: %s", reg.registration.Name, err.Error()))
+	}
+}
+
+// Send the aggregated events of the windows that are due, all of them if now is zero
+// The events aggregated are settled once the aggregated event is sent
+func (reg registrationInfo) flushAggregates(now time.Time) {
+	if reg.aggregator == nil {
 		return
 	}
+	for _, w := range reg.aggregator.due(now) {
+		data := w.event(reg.aggregator.value)
+		if !reg.deliver(data, &models.Event{Event: *data}, w.ids, w.keys) {
+			LoggingClient.Error(fmt.Sprintf("Failed sending aggregated event of %s with registration %s", w.device, reg.registration.Name))
+		}
+	}
+}
+
+// Format, compress and encrypt the data of an event, nil if it can not be formatted
+// Formatting it again would fail the same way, so it is not sent
+func (reg registrationInfo) payload(data *contract.Event) []byte {
 	formatted := reg.format.Format(data)
+	if len(formatted) == 0 {
+		LoggingClient.Error(fmt.Sprintf("Event %s not formatted with registration %s, it is not sent", data.ID, reg.registration.Name))
+		return nil
+	}
+	return reg.encode(formatted)
+}
 
+// Compress and encrypt formatted data
+func (reg registrationInfo) encode(formatted []byte) []byte {
 	compressed := formatted
 	if reg.compression != nil {
 		compressed = reg.compression.Transform(formatted)
@@ -190,26 +566,124 @@ func (reg registrationInfo) processEvent(event *models.Event) {
 	if reg.encrypt != nil {
 		encrypted = reg.encrypt.Transform(compressed)
 	}
+	return encrypted
+}
 
-	if reg.sender.Send(encrypted, event) && Configuration.Writable.MarkPushed {
-		id := event.ID
-		err := ec.MarkPushed(id, context.Background())
-		if err != nil {
-			LoggingClient.Error(fmt.Sprintf("Failed to mark event as pushed : event ID = %s: %s", id, err))
+// Send the batch in a single payload when it is due, whatever its size if now is zero
+// The destination gets the first event of the batch as the event sent, to resolve the topic for example
+// A batch that fails is kept to be sent again, return false in that case
+func (reg registrationInfo) flushBatch(now time.Time) bool {
+	if reg.batch == nil || !reg.batch.due(now) {
+		return true
+	}
+
+	events := reg.batch.events
+	formatted := reg.format.(batchFormatter).FormatBatch(events)
+	if len(formatted) == 0 {
+		LoggingClient.Error(fmt.Sprintf("Batch of %d events not formatted with registration %s", len(events), reg.registration.Name))
+		return false
+	}
+	event := &models.Event{Event: *events[0]}
+
+	// The batch in flight is settled once the sender confirms it
+	var delivery *inFlightDelivery
+	if reg.async {
+		delivery = newInFlightDelivery(reg.batch.ids, reg.batch.keys, len(events))
+		delivery.formatted = events
+	}
+	if !reg.sendTracked(delivery, reg.encode(formatted), event) {
+		for range events {
+			reg.countSent(false)
 		}
+		LoggingClient.Error(fmt.Sprintf("Failed sending batch of %d events with registration %s", len(events), reg.registration.Name))
+		return false
+	}
+	_, ids, keys := reg.batch.take()
+	if delivery != nil && !reg.inFlight.sent(delivery) {
+		LoggingClient.Debug(fmt.Sprintf("Sending batch of %d events with registration: %s", len(events), reg.registration.Name))
+		return true
+	}
+	for range events {
+		reg.countSent(true)
+	}
+	reg.settle(ids, keys, true)
+	reg.settleFormatted(events)
+	LoggingClient.Debug(fmt.Sprintf("Sent batch of %d events with registration: %s", len(events), reg.registration.Name))
+	return true
+}
+
+// Send again the events this registration has not delivered, others may have pushed them
+// The queue is the only delivery path of store-and-forward, it holds the events not sent yet
+func (reg registrationInfo) replayUnpushed() {
+	if !Configuration.Writable.MarkPushed || reg.queue != nil {
+		return
+	}
+	events, err := ec.EventsUnpushedForRegistration(reg.registration.Name, 100, context.Background())
+	if err != nil {
+		LoggingClient.Error(fmt.Sprintf("Failed getting events to send non-pushed %s", err.Error()))
 	}
 
-	LoggingClient.Debug(fmt.Sprintf("Sent event with registration: %s", reg.registration.Name))
+	if len(events) != 0 {
+		LoggingClient.Info("Pushing unpushed events")
+		for i := range events {
+			correlationID := uuid.New()
+			ev := models.Event{CorrelationId: correlationID.String(), Event: events[i]}
+			reg.processEvent(&ev)
+		}
+	}
 }
 
 func registrationLoop(reg *registrationInfo) {
 	LoggingClient.Info(fmt.Sprintf("registration loop started: %s", reg.registration.Name))
 	timerPush := time.NewTimer(pushEventsTimer * time.Second)
+	tickerFlush := time.NewTicker(flushTimer * time.Second)
+	defer tickerFlush.Stop()
+
+	// Replay the events queued before the registration was started
+	var retry <-chan time.Time
+	if reg.queue != nil {
+		interval := Configuration.StoreAndForward.RetryInterval
+		if interval <= 0 {
+			interval = defaultRetryInterval
+		}
+		ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+		defer ticker.Stop()
+		retry = ticker.C
+
+		if reg.registration.Enable {
+			reg.forward()
+		}
+	}
+
 	for {
 		select {
 		case event := <-reg.chEvent:
-			if reg.registration.Enable {
+			if !reg.registration.Enable {
+				break
+			}
+			if reg.queue == nil {
+				reg.processEvent(event)
+				break
+			}
+
+			// Queue the event before sending it so it survives a destination or distro failure
+			if err := reg.store(event); err != nil {
+				LoggingClient.Error(fmt.Sprintf("Failed queuing event for registration %s: %s", reg.registration.Name, err.Error()))
 				reg.processEvent(event)
+				break
+			}
+			reg.forward()
+
+		case <-reg.inFlight.notify:
+			reg.settleInFlight()
+
+		case now := <-tickerFlush.C:
+			reg.flushAggregates(now)
+			reg.flushBatch(now)
+
+		case <-retry:
+			if reg.registration.Enable {
+				reg.forward()
 			}
 
 		case newReg := <-reg.chRegistration:
@@ -221,26 +695,12 @@ func registrationLoop(reg *registrationInfo) {
 					LoggingClient.Info(fmt.Sprintf("Registration %s updated: OK", reg.registration.Name))
 				} else {
 					LoggingClient.Info(fmt.Sprintf("Registration %s updated: OK, terminating goroutine", reg.registration.Name))
-					reg.deleteFlag = true
+					reg.setDeleted()
 					return
 				}
 			}
 		case <-timerPush.C:
-			if Configuration.Writable.MarkPushed {
-				events, err := ec.EventsUnpushed(context.Background(), 100)
-				if err != nil {
-					LoggingClient.Error(fmt.Sprintf("Failed getting events to send non-pushed %s", err.Error()))
-				}
-
-				if len(events) != 0 {
-					LoggingClient.Info("Pushing unpushed events")
-					for i := range events {
-						correlationID := uuid.New()
-						ev := models.Event{CorrelationId: correlationID.String(), Event: events[i]}
-						reg.processEvent(&ev)
-					}
-				}
-			}
+			reg.replayUnpushed()
 			timerPush.Reset(pushEventsTimer * time.Second)
 		}
 	}
@@ -253,8 +713,17 @@ func updateRunningRegistrations(running map[string]*registrationInfo,
 	case contract.NotifyUpdateDelete:
 		for k, v := range running {
 			if k == update.Name {
-				v.chRegistration <- nil
+				v.notify(nil)
 				delete(running, k)
+				untrackRegistration(k)
+				if v.queue != nil {
+					if err := v.queue.drop(); err != nil {
+						return fmt.Errorf("could not remove the queue of %s: %v", k, err)
+					}
+				}
+				if err := dropDexmaSequences(k); err != nil {
+					return fmt.Errorf("could not remove the Dexma sequence numbers of %s: %v", k, err)
+				}
 				return nil
 			}
 		}
@@ -266,7 +735,11 @@ func updateRunningRegistrations(running map[string]*registrationInfo,
 		}
 		for k, v := range running {
 			if k == update.Name {
-				v.chRegistration <- reg
+				if !v.notify(reg) {
+					delete(running, k)
+					untrackRegistration(k)
+					return fmt.Errorf("Registration %s terminated after an invalid update", k)
+				}
 				return nil
 			}
 		}
@@ -279,6 +752,7 @@ func updateRunningRegistrations(running map[string]*registrationInfo,
 		regInfo := newRegistrationInfo()
 		if regInfo.update(*reg) {
 			running[reg.Name] = regInfo
+			trackRegistration(reg.Name, regInfo)
 			go registrationLoop(regInfo)
 		}
 		return nil
@@ -288,7 +762,7 @@ func updateRunningRegistrations(running map[string]*registrationInfo,
 }
 
 // Loop - registration loop
-func Loop(errChan chan error, eventCh chan *models.Event) {
+func Loop(errChan chan error, eventCh <-chan *models.Event) {
 	go func() {
 		p := fmt.Sprintf(":%d", Configuration.Service.Port)
 		LoggingClient.Info(fmt.Sprintf("Starting Export Distro %s", p))
@@ -318,6 +792,7 @@ func Loop(errChan chan error, eventCh chan *models.Event) {
 		regInfo := newRegistrationInfo()
 		if regInfo.update(reg) {
 			registrations[reg.Name] = regInfo
+			trackRegistration(reg.Name, regInfo)
 			go registrationLoop(regInfo)
 		}
 	}
@@ -328,11 +803,10 @@ func Loop(errChan chan error, eventCh chan *models.Event) {
 		case e := <-errChan:
 			// kill all registration goroutines
 			for k, reg := range registrations {
-				if !reg.deleteFlag {
-					// Do not write in channel that will not be read
-					reg.chRegistration <- nil
-				}
+				// Do not wait for goroutines that already terminated
+				reg.notify(nil)
 				delete(registrations, k)
+				untrackRegistration(k)
 			}
 			LoggingClient.Error(fmt.Sprintf("exit msg: %s", e.Error()))
 			return
@@ -347,11 +821,11 @@ func Loop(errChan chan error, eventCh chan *models.Event) {
 
 		case event := <-eventCh:
 			for k, reg := range registrations {
-				if reg.deleteFlag {
+				if reg.deleted() {
 					delete(registrations, k)
+					untrackRegistration(k)
 				} else {
-					// TODO only sent event if it is not blocking
-					reg.chEvent <- event
+					reg.queueEvent(event)
 				}
 			}
 		}
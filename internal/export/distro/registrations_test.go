@@ -76,8 +76,9 @@ func (sender *dummyStruct) Send(data []byte, event *models.Event) bool {
 	return true
 }
 
+// Empty payloads are not sent, they are returned when the format fails
 func (sender *dummyStruct) Format(ev *contract.Event) []byte {
-	return []byte("")
+	return []byte("dummy")
 }
 
 func (sender *dummyStruct) Transform(data []byte) []byte {
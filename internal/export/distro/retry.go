@@ -0,0 +1,176 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+// States of the circuit breaker of a registration
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+
+	defaultRetryAttempts    = 3
+	defaultRetryBaseDelay   = 500
+	defaultRetryMaxDelay    = 10000
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 60000
+)
+
+// Stops sending to a destination after several failed sends in a row
+// Once the open timeout has elapsed a single send is let through to probe the destination
+type circuitBreaker struct {
+	mutex    sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{state: CircuitClosed}
+}
+
+// Return true if a send can be attempted
+func (cb *circuitBreaker) allow() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		timeout := Configuration.Retry.OpenTimeout
+		if timeout <= 0 {
+			timeout = defaultOpenTimeout
+		}
+		if time.Since(cb.openedAt) < time.Duration(timeout)*time.Millisecond {
+			return false
+		}
+		cb.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		// Only the probe is let through
+		return false
+	default:
+		return true
+	}
+}
+
+// Record the result of a send
+func (cb *circuitBreaker) record(ok bool) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	if ok {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+
+	threshold := Configuration.Retry.FailureThreshold
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= threshold {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+func (cb *circuitBreaker) currentState() string {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.state
+}
+
+// Sender retrying the sends with an exponential backoff behind a circuit breaker
+type retrySender struct {
+	name    string
+	next    sender
+	breaker *circuitBreaker
+}
+
+func newRetrySender(name string, next sender, breaker *circuitBreaker) sender {
+	return &retrySender{name: name, next: next, breaker: breaker}
+}
+
+func (sender *retrySender) Send(data []byte, event *models.Event) bool {
+	if !sender.breaker.allow() {
+		LoggingClient.Debug(fmt.Sprintf("Circuit of registration %s open, event not sent", sender.name))
+		return false
+	}
+
+	attempts := Configuration.Retry.Attempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := backoff(attempt)
+			LoggingClient.Debug(fmt.Sprintf("Retrying send of registration %s in %s", sender.name, delay))
+			time.Sleep(delay)
+		}
+		if sender.next.Send(data, event) {
+			sender.breaker.record(true)
+			return true
+		}
+	}
+
+	sender.breaker.record(false)
+	if sender.breaker.currentState() == CircuitOpen {
+		LoggingClient.Warn(fmt.Sprintf("Circuit of registration %s open after %d failed attempts", sender.name, attempts))
+	}
+	return false
+}
+
+// Pass the function to the wrapped sender if it confirms the sends asynchronously
+// Those failures also count for the circuit breaker
+func (sender *retrySender) onComplete(completed func(event *models.Event, delivered bool)) bool {
+	async, ok := sender.next.(asyncSender)
+	if !ok {
+		return false
+	}
+	return async.onComplete(func(event *models.Event, delivered bool) {
+		if !delivered {
+			sender.breaker.record(false)
+		}
+		completed(event, delivered)
+	})
+}
+
+// Delay before the given retry, doubling from the base delay up to the max delay
+// The jitter randomly shortens it by up to that fraction
+func backoff(attempt int) time.Duration {
+	info := Configuration.Retry
+	base := info.BaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	max := info.MaxDelay
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	delay := float64(base)
+	for i := 1; i < attempt && delay < float64(max); i++ {
+		delay *= 2
+	}
+	if delay > float64(max) {
+		delay = float64(max)
+	}
+	if info.Jitter > 0 && info.Jitter <= 1 {
+		delay -= delay * info.Jitter * rand.Float64()
+	}
+	return time.Duration(delay * float64(time.Millisecond))
+}
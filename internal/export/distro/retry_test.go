@@ -0,0 +1,116 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+// Sender failing the given number of sends before accepting them
+type failingSender struct {
+	failures int
+	calls    int
+}
+
+func (sender *failingSender) Send(data []byte, event *models.Event) bool {
+	sender.calls++
+	return sender.calls > sender.failures
+}
+
+func setRetryConfig(info RetryInfo) func() {
+	previous := Configuration.Retry
+	Configuration.Retry = info
+	return func() {
+		Configuration.Retry = previous
+	}
+}
+
+func TestRetrySender(t *testing.T) {
+	defer setRetryConfig(RetryInfo{Attempts: 3, BaseDelay: 1, MaxDelay: 2})()
+
+	next := &failingSender{failures: 2}
+	breaker := newCircuitBreaker()
+	s := newRetrySender("test", next, breaker)
+	if !s.Send([]byte{}, &models.Event{}) {
+		t.Errorf("The event should be sent on the third attempt")
+	}
+	if next.calls != 3 {
+		t.Errorf("There should be 3 attempts, not %d", next.calls)
+	}
+	if breaker.currentState() != CircuitClosed {
+		t.Errorf("The circuit should be closed, not %s", breaker.currentState())
+	}
+
+	next = &failingSender{failures: 5}
+	s = newRetrySender("test", next, breaker)
+	if s.Send([]byte{}, &models.Event{}) {
+		t.Errorf("The event should not be sent")
+	}
+	if next.calls != 3 {
+		t.Errorf("There should be 3 attempts, not %d", next.calls)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	defer setRetryConfig(RetryInfo{Attempts: 1, FailureThreshold: 2, OpenTimeout: 10})()
+
+	next := &failingSender{failures: 3}
+	breaker := newCircuitBreaker()
+	s := newRetrySender("test", next, breaker)
+
+	s.Send([]byte{}, &models.Event{})
+	if breaker.currentState() != CircuitClosed {
+		t.Errorf("The circuit should be closed, not %s", breaker.currentState())
+	}
+	s.Send([]byte{}, &models.Event{})
+	if breaker.currentState() != CircuitOpen {
+		t.Fatalf("The circuit should be open, not %s", breaker.currentState())
+	}
+
+	// The destination is not called while the circuit is open
+	s.Send([]byte{}, &models.Event{})
+	if next.calls != 2 {
+		t.Errorf("There should be 2 sends, not %d", next.calls)
+	}
+
+	// The failed probe opens the circuit again
+	time.Sleep(20 * time.Millisecond)
+	if s.Send([]byte{}, &models.Event{}) || breaker.currentState() != CircuitOpen {
+		t.Errorf("The circuit should be open again, not %s", breaker.currentState())
+	}
+
+	// The successful probe closes it
+	time.Sleep(20 * time.Millisecond)
+	if !s.Send([]byte{}, &models.Event{}) || breaker.currentState() != CircuitClosed {
+		t.Errorf("The circuit should be closed, not %s", breaker.currentState())
+	}
+	if next.calls != 4 {
+		t.Errorf("There should be 4 sends, not %d", next.calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	defer setRetryConfig(RetryInfo{BaseDelay: 100, MaxDelay: 300})()
+
+	expected := []time.Duration{100, 200, 300, 300}
+	for i, delay := range expected {
+		if d := backoff(i + 1); d != delay*time.Millisecond {
+			t.Errorf("Retry %d should wait %s, not %s", i+1, delay*time.Millisecond, d)
+		}
+	}
+
+	Configuration.Retry.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		if d := backoff(1); d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Errorf("Retry with jitter should wait between 50ms and 100ms, not %s", d)
+		}
+	}
+}
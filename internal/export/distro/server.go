@@ -64,7 +64,13 @@ func replyNotifyRegistrations(w http.ResponseWriter, r *http.Request) {
 }
 
 func metricsHandler(w http.ResponseWriter, _ *http.Request) {
-	s := telemetry.NewSystemUsage()
+	s := struct {
+		telemetry.SystemUsage
+		Registrations map[string]RegistrationStats
+	}{
+		SystemUsage:   telemetry.NewSystemUsage(),
+		Registrations: registrationStats(),
+	}
 
 	encode(s, w)
 
@@ -0,0 +1,73 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/template"
+	"time"
+
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Formatter rendering the events with the Go template of the registration
+// Besides the builtin functions the templates can use:
+//
+//	timestamp <millis> <format>  origin formatted as MILLIS, SECONDS, RFC3339 or a Go time layout
+//	now                          current time in milliseconds
+//	number <string>              value parsed as a float
+//	integer <string>             value parsed as an integer
+//	json <value>                 value encoded as JSON, quoted and escaped for strings
+//	label <device> <key>         value of a key=value label of the device in metadata
+type templateFormatter struct {
+	template *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"timestamp": formatTimestamp,
+	"now": func() int64 {
+		return time.Now().UnixNano() / int64(time.Millisecond)
+	},
+	"number": func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	},
+	"integer": func(value string) (int64, error) {
+		return strconv.ParseInt(value, 10, 64)
+	},
+	"json": func(value interface{}) (string, error) {
+		b, err := json.Marshal(value)
+		return string(b), err
+	},
+	"label": func(device, key string) (string, error) {
+		labels, err := deviceLabels(device)
+		if err != nil {
+			return "", err
+		}
+		return labels[key], nil
+	},
+}
+
+func newTemplateFormatter(text string) (templateFormatter, error) {
+	t, err := template.New("registration").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return templateFormatter{}, err
+	}
+	return templateFormatter{template: t}, nil
+}
+
+func (tf templateFormatter) Format(event *contract.Event) []byte {
+	var b bytes.Buffer
+	if err := tf.template.Execute(&b, event); err != nil {
+		LoggingClient.Error(fmt.Sprintf("Error rendering template of event %s. Error: %s", event.ID, err.Error()))
+		return nil
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"testing"
+
+	"github.com/Circutor/edgex/pkg/clients/metadata/mocks"
+	contract "github.com/Circutor/edgex/pkg/models"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	mdcMock := &mocks.DeviceClient{}
+	mdcMock.On("DeviceForName", "meter2", mock.Anything).Return(contract.Device{Labels: []string{"site=plant \"1\""}}, nil)
+	mdc = mdcMock
+	defer func() { mdc = nil }()
+
+	eventIn := contract.Event{
+		ID:       "id1",
+		Device:   "meter2",
+		Origin:   1577836800000,
+		Readings: []contract.Reading{{Name: "voltage", Value: "230.5"}, {Name: "count", Value: "7"}},
+	}
+
+	var tests = []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"fields", `{{.Device}}:{{range .Readings}}{{.Name}}={{.Value}};{{end}}`, "meter2:voltage=230.5;count=7;"},
+		{"timestamp", `{{timestamp .Origin "RFC3339"}} {{timestamp .Origin "SECONDS"}}`, "2020-01-01T00:00:00Z 1577836800"},
+		{"numbers", `{{with index .Readings 0}}{{number .Value}}{{end}} {{with index .Readings 1}}{{integer .Value}}{{end}}`, "230.5 7"},
+		{"json", `{"site":{{json (label .Device "site")}}}`, `{"site":"plant \"1\""}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf, err := newTemplateFormatter(tt.template)
+			if err != nil {
+				t.Fatalf("Error parsing template: %v", err)
+			}
+			out := tf.Format(&eventIn)
+			if string(out) != tt.expected {
+				t.Errorf("Invalid template output: %s, expected %s", out, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatterErrors(t *testing.T) {
+	if _, err := newTemplateFormatter("{{.Device"); err == nil {
+		t.Errorf("Invalid templates should fail")
+	}
+	if _, err := newTemplateFormatter("{{unknown .Device}}"); err == nil {
+		t.Errorf("Unknown functions should fail")
+	}
+
+	tf, _ := newTemplateFormatter("{{range .Readings}}{{number .Value}}{{end}}")
+	if out := tf.Format(&contract.Event{Readings: []contract.Reading{{Value: "on"}}}); out != nil {
+		t.Errorf("Rendering errors should not return data")
+	}
+}
+
+func TestTemplateFuncsDeclared(t *testing.T) {
+	if len(templateFuncs) != len(contract.TemplateFuncs) {
+		t.Fatalf("The registrations validate templates with %v", contract.TemplateFuncs)
+	}
+	for _, name := range contract.TemplateFuncs {
+		if _, ok := templateFuncs[name]; !ok {
+			t.Errorf("Function %s of the registration templates not implemented", name)
+		}
+	}
+}
+
+func TestTemplateFormatFailed(t *testing.T) {
+	sender := &flakySender{up: true}
+	reg := newRegistrationInfo()
+	reg.registration = contract.Registration{Name: "template", Enable: true}
+	reg.format, _ = newTemplateFormatter("{{range .Readings}}{{number .Value}}{{end}}")
+	reg.sender = sender
+
+	// The event that can not be rendered is handled without sending an empty payload
+	event := newTestEvent("1")
+	event.Readings = []contract.Reading{{Name: "STATE", Value: "on"}}
+	if !reg.processEvent(event) || len(sender.sent) != 0 {
+		t.Errorf("The event should not be sent, sent %v", sender.sent)
+	}
+	if reg.counters.failed != 1 {
+		t.Errorf("The event should be counted as failed")
+	}
+}
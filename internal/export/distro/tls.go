@@ -0,0 +1,55 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Build the TLS configuration of an addressable
+// The server certificate is verified against the CA bundle, or the system one if it is empty,
+// unless InsecureSkipVerify is set
+func newTLSConfig(addr contract.Addressable) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         addr.ServerName,
+		InsecureSkipVerify: addr.InsecureSkipVerify,
+	}
+
+	if addr.CACertificate != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(addr.CACertificate)) {
+			return nil, fmt.Errorf("no valid CA certificate found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if addr.Certificate != "" {
+		cert, err := tls.X509KeyPair([]byte(addr.Certificate), []byte(addr.Key))
+		if err != nil {
+			return nil, fmt.Errorf("failed loading x509 data: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Registrations created before the key had its own field keep it in the password
+// Move it to the key so it is not sent as the broker password
+func withLegacyKey(addr contract.Addressable) contract.Addressable {
+	if addr.Certificate != "" && addr.Key == "" && addr.Password != "" {
+		LoggingClient.Warn(fmt.Sprintf("Addressable %s has the certificate key in the password, use the key instead", addr.Name))
+		addr.Key = addr.Password
+		addr.Password = ""
+	}
+	return addr
+}
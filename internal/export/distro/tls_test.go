@@ -0,0 +1,98 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Return a self-signed certificate and its key in PEM
+func newTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "broker"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Error creating certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Error encoding key: %v", err)
+	}
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(cert), string(keyPem)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	cert, key := newTestCertificate(t)
+
+	tlsConfig, err := newTLSConfig(contract.Addressable{})
+	if err != nil {
+		t.Fatalf("Error creating TLS configuration: %v", err)
+	}
+	if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) != 0 {
+		t.Errorf("The server should be verified against the system CAs")
+	}
+
+	tlsConfig, err = newTLSConfig(contract.Addressable{
+		CACertificate: cert,
+		ServerName:    "broker",
+		Certificate:   cert,
+		Key:           key,
+	})
+	if err != nil {
+		t.Fatalf("Error creating TLS configuration: %v", err)
+	}
+	if tlsConfig.RootCAs == nil || tlsConfig.ServerName != "broker" || len(tlsConfig.Certificates) != 1 {
+		t.Errorf("The CA, server name and client certificate should be configured")
+	}
+
+	if _, err = newTLSConfig(contract.Addressable{CACertificate: "invalid"}); err == nil {
+		t.Errorf("Invalid CA certificates should fail")
+	}
+	if _, err = newTLSConfig(contract.Addressable{Certificate: cert, Password: key}); err == nil {
+		t.Errorf("The key should not be read from the password")
+	}
+}
+
+func TestWithLegacyKey(t *testing.T) {
+	cert, key := newTestCertificate(t)
+
+	addr := withLegacyKey(contract.Addressable{Certificate: cert, Password: key})
+	if addr.Key != key || addr.Password != "" {
+		t.Errorf("The key in the password should be moved to the key")
+	}
+
+	addr = withLegacyKey(contract.Addressable{Certificate: cert, Key: key, Password: "secret"})
+	if addr.Key != key || addr.Password != "secret" {
+		t.Errorf("The password should be kept when there is a key")
+	}
+
+	if _, err := newTLSConfig(withLegacyKey(contract.Addressable{Certificate: cert, Password: key})); err != nil {
+		t.Errorf("Error creating TLS configuration: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	contract "github.com/Circutor/edgex/pkg/models"
+)
+
+// Placeholders of the topic templates
+const (
+	topicDevice      = "device"
+	topicEventID     = "id"
+	topicReadingName = "reading.name"
+	topicLabelPrefix = "label:"
+
+	labelsCacheTime = 5 * time.Minute
+)
+
+// Topic with placeholders between braces resolved for each event, such as site/{device}/{reading.name}
+// or tenants/{label:tenant}/{device}/telemetry. The labels are the key=value (or key:value) labels of the device in metadata
+type topicTemplate struct {
+	template   string
+	perReading bool // It needs an event per reading
+	usesLabels bool
+}
+
+// Labels of the devices read from metadata
+var labelsCache = struct {
+	sync.Mutex
+	byDevice map[string]cachedLabels
+}{byDevice: make(map[string]cachedLabels)}
+
+type cachedLabels struct {
+	labels  map[string]string
+	expires time.Time
+}
+
+// Return the template of the topic, nil if it has no placeholders
+func newTopicTemplate(topic string) (*topicTemplate, error) {
+	if !strings.Contains(topic, "{") {
+		return nil, nil
+	}
+
+	t := &topicTemplate{template: topic}
+	for _, name := range placeholders(topic) {
+		switch {
+		case name == topicDevice, name == topicEventID:
+		case name == topicReadingName:
+			t.perReading = true
+		case strings.HasPrefix(name, topicLabelPrefix) && len(name) > len(topicLabelPrefix):
+			t.usesLabels = true
+		default:
+			return nil, fmt.Errorf("unknown placeholder %s in topic %s", name, topic)
+		}
+	}
+	return t, nil
+}
+
+// Return true if the events must be published one reading at a time
+func splitsReadings(addr contract.Addressable) bool {
+	if addr.MQTT.SplitReadings {
+		return true
+	}
+	t, err := newTopicTemplate(addr.Topic)
+	return err == nil && t != nil && t.perReading
+}
+
+// Names of the placeholders of a template
+func placeholders(template string) []string {
+	var names []string
+	for {
+		start := strings.Index(template, "{")
+		if start < 0 {
+			return names
+		}
+		end := strings.Index(template[start:], "}")
+		if end < 0 {
+			return names
+		}
+		names = append(names, template[start+1:start+end])
+		template = template[start+end+1:]
+	}
+}
+
+// Return the topic of an event, placeholders without value are left empty
+func (t *topicTemplate) resolve(event contract.Event) (string, error) {
+	var labels map[string]string
+	if t.usesLabels {
+		var err error
+		labels, err = deviceLabels(event.Device)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	topic := t.template
+	for _, name := range placeholders(t.template) {
+		var value string
+		switch {
+		case name == topicDevice:
+			value = event.Device
+		case name == topicEventID:
+			value = event.ID
+		case name == topicReadingName:
+			if len(event.Readings) != 1 {
+				return "", fmt.Errorf("topic %s needs events with a single reading", t.template)
+			}
+			value = event.Readings[0].Name
+		case strings.HasPrefix(name, topicLabelPrefix):
+			value = labels[strings.TrimPrefix(name, topicLabelPrefix)]
+		default:
+			return "", fmt.Errorf("unknown placeholder %s in topic %s", name, t.template)
+		}
+
+		if value == "" {
+			LoggingClient.Warn(fmt.Sprintf("No value for %s in topic %s, event %s", name, t.template, event.ID))
+		}
+		topic = strings.Replace(topic, "{"+name+"}", topicLevel(value), 1)
+	}
+	return topic, nil
+}
+
+// Make a value a single topic level without wildcards
+func topicLevel(value string) string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(value)
+}
+
+// Return the labels of a device by key, caching them for a while
+// The labels that are not key=value have an empty value
+func deviceLabels(device string) (map[string]string, error) {
+	labelsCache.Lock()
+	cached, ok := labelsCache.byDevice[device]
+	labelsCache.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.labels, nil
+	}
+
+	d, err := mdc.DeviceForName(device, context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not get the labels of device %s: %v", device, err)
+	}
+
+	labels := make(map[string]string)
+	for _, label := range d.Labels {
+		if i := strings.IndexAny(label, "=:"); i > 0 {
+			labels[label[:i]] = label[i+1:]
+		} else {
+			labels[label] = ""
+		}
+	}
+
+	labelsCache.Lock()
+	labelsCache.byDevice[device] = cachedLabels{labels: labels, expires: time.Now().Add(labelsCacheTime)}
+	labelsCache.Unlock()
+	return labels, nil
+}
@@ -0,0 +1,92 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package distro
+
+import (
+	"testing"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+	"github.com/Circutor/edgex/pkg/clients/metadata/mocks"
+	contract "github.com/Circutor/edgex/pkg/models"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTopicTemplate(t *testing.T) {
+	mdcMock := &mocks.DeviceClient{}
+	mdcMock.On("DeviceForName", "meter/1", mock.Anything).Return(contract.Device{Labels: []string{"tenant=acme", "site:plant1", "power"}}, nil).Once()
+	mdc = mdcMock
+	defer func() { mdc = nil }()
+
+	event := contract.Event{ID: "id1", Device: "meter/1", Readings: []contract.Reading{{Name: "voltage"}}}
+	var tests = []struct {
+		topic    string
+		expected string
+	}{
+		{"site/{device}/{reading.name}", "site/meter_1/voltage"},
+		{"tenants/{label:tenant}/{label:site}/{device}/telemetry", "tenants/acme/plant1/meter_1/telemetry"},
+		{"events/{id}/{label:unknown}", "events/id1/"},
+	}
+	for _, tt := range tests {
+		template, err := newTopicTemplate(tt.topic)
+		if err != nil {
+			t.Fatalf("Error creating template %s: %v", tt.topic, err)
+		}
+		topic, err := template.resolve(event)
+		if err != nil {
+			t.Fatalf("Error resolving template %s: %v", tt.topic, err)
+		}
+		if topic != tt.expected {
+			t.Errorf("Topic should be %s, not %s", tt.expected, topic)
+		}
+	}
+
+	// The labels are read once
+	mdcMock.AssertExpectations(t)
+}
+
+func TestTopicTemplateErrors(t *testing.T) {
+	if template, err := newTopicTemplate("static/topic"); template != nil || err != nil {
+		t.Errorf("Static topics should not have a template")
+	}
+	if _, err := newTopicTemplate("site/{unknown}"); err == nil {
+		t.Errorf("Unknown placeholders should fail")
+	}
+
+	template, _ := newTopicTemplate("site/{reading.name}")
+	event := contract.Event{Readings: []contract.Reading{{Name: "voltage"}, {Name: "current"}}}
+	if _, err := template.resolve(event); err == nil {
+		t.Errorf("Reading placeholders should fail with several readings")
+	}
+}
+
+func TestSplitReadings(t *testing.T) {
+	if !splitsReadings(contract.Addressable{Topic: "site/{device}/{reading.name}"}) {
+		t.Errorf("Reading placeholders should split the events")
+	}
+	if !splitsReadings(contract.Addressable{Topic: "site", MQTT: contract.MQTTOptions{SplitReadings: true}}) {
+		t.Errorf("The option should split the events")
+	}
+	if splitsReadings(contract.Addressable{Topic: "site/{device}"}) {
+		t.Errorf("The events should not be split")
+	}
+
+	dummy := &dummyStruct{}
+	reg := registrationInfo{
+		format:        dummy,
+		sender:        dummy,
+		counters:      &eventCounters{},
+		splitReadings: true,
+	}
+	event := &models.Event{Event: contract.Event{Readings: []contract.Reading{{Name: "voltage"}, {Name: "current"}}}}
+	if !reg.processEvent(event) {
+		t.Errorf("The event should be sent")
+	}
+	if dummy.count != 2 {
+		t.Errorf("There should be a send per reading, not %d", dummy.count)
+	}
+}
@@ -19,11 +19,38 @@ type sender interface {
 	Send(data []byte, event *models.Event) bool
 }
 
+// Sender confirming some sends after Send has returned
+// The completed function is called with the events of those sends once the destination
+// accepted them or not. Return false if the sends are confirmed when Send returns
+type asyncSender interface {
+	sender
+	onComplete(completed func(event *models.Event, delivered bool)) bool
+}
+
 // Formatter - Format interface
 type formatter interface {
 	Format(event *contract.Event) []byte
 }
 
+// Formatter whose Format has side effects, like advancing sequence numbers
+// The size is the length of the formatted event without them
+type sizedFormatter interface {
+	formatter
+	size(event *contract.Event) int
+}
+
+// Formatter keeping state of the events formatted until they are delivered
+type settledFormatter interface {
+	formatter
+	settle(events []*contract.Event)
+}
+
+// Formatter of several events in a single payload
+type batchFormatter interface {
+	formatter
+	FormatBatch(events []*contract.Event) []byte
+}
+
 // Transformer - Transform interface
 type transformer interface {
 	Transform(data []byte) []byte
@@ -33,3 +60,9 @@ type transformer interface {
 type filterer interface {
 	Filter(event *contract.Event) (bool, *contract.Event)
 }
+
+// Filter keeping state of the events accepted until they are delivered, or failed to be
+type settledFilterer interface {
+	filterer
+	settle(ids []string, delivered bool)
+}
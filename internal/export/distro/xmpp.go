@@ -20,6 +20,7 @@ import (
 )
 
 type xmppSender struct {
+	options xmpp.Options
 	client  *xmpp.Client
 	remote  string
 	subject string
@@ -47,22 +48,36 @@ func newXMPPSender(addr contract.Addressable) sender {
 		Session:  false,
 	}
 
-	xmppClient, err := options.NewClient()
-	if err != nil {
-		LoggingClient.Error(err.Error())
-	}
-
 	sender := &xmppSender{
-		client: xmppClient,
+		options: options,
 	}
+	sender.connect()
 
 	return sender
 }
 
+// Connect to the server, leaving the client nil if it fails
+func (sender *xmppSender) connect() bool {
+	xmppClient, err := sender.options.NewClient()
+	if err != nil {
+		LoggingClient.Error(err.Error())
+		return false
+	}
+	sender.client = xmppClient
+	return true
+}
+
 func (sender *xmppSender) Send(data []byte, event *models.Event) bool {
+	if sender.client == nil {
+		LoggingClient.Info("Connecting to xmpp server")
+		if !sender.connect() {
+			return false
+		}
+	}
+
 	stringData := string(data)
 
-	sender.client.Send(xmpp.Chat{
+	_, err := sender.client.Send(xmpp.Chat{
 		Text:    stringData,
 		Remote:  sender.remote,
 		Subject: sender.subject,
@@ -70,6 +85,13 @@ func (sender *xmppSender) Send(data []byte, event *models.Event) bool {
 		Other:   sender.other,
 		Stamp:   sender.stamp,
 	})
+	if err != nil {
+		// Reconnect on the next send
+		LoggingClient.Error(err.Error())
+		sender.client.Close()
+		sender.client = nil
+		return false
+	}
 
 	return true
 }
@@ -0,0 +1,138 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package bus holds the message bus types shared by core-data and export-distro
+// and the in-process channel used when both run in the same binary.
+package bus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+const (
+	// Message bus types
+	ZeroMQ  = "zero" // Mangos sockets, kept for backward compatibility
+	Mangos  = "mangos"
+	Channel = "channel"
+	MQTT    = "mqtt"
+
+	// Topic of the events when the bus has topics
+	DefaultTopic = "edgex/events"
+
+	defaultBufferSize     = 100
+	defaultPublishTimeout = time.Second
+)
+
+// Counters of the in-process channel
+type Stats struct {
+	Published uint64 // Events handed to the receiver
+	Blocked   uint64 // Events that found the channel full and had to wait
+	Dropped   uint64 // Events dropped because the channel was still full after the timeout
+	Queued    int    // Events waiting for the receiver
+	Capacity  int
+}
+
+var (
+	mutex     sync.Mutex
+	events    chan *models.Event
+	timeout   = defaultPublishTimeout
+	receiver  bool // The in-process channel has a receiver, only in the all-in-one binary
+	published uint64
+	blocked   uint64
+	dropped   uint64
+)
+
+// Set whether the events of the in-process channel are received in this process
+// Publishing on the channel is only possible when they are
+func SetReceiver(inProcess bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	receiver = inProcess
+}
+
+// Return true if the events of the in-process channel are received in this process
+func HasReceiver() bool {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return receiver
+}
+
+// Set the capacity of the in-process channel and how long publishers wait while it is full
+// A zero or negative timeout drops the events as soon as the channel is full
+// It must be called before publishing or receiving the first event
+func Configure(bufferSize int, publishTimeout time.Duration) {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	events = make(chan *models.Event, bufferSize)
+	timeout = publishTimeout
+}
+
+// Return the in-process channel and the publish timeout, creating the channel with the default size if needed
+func current() (chan *models.Event, time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if events == nil {
+		events = make(chan *models.Event, defaultBufferSize)
+	}
+	return events, timeout
+}
+
+// Publish an event on the in-process channel
+// Block up to the publish timeout while the channel is full and drop the event after it
+// Return false if the event has been dropped
+func Publish(e *models.Event) bool {
+	ch, timeout := current()
+
+	select {
+	case ch <- e:
+		atomic.AddUint64(&published, 1)
+		return true
+	default:
+	}
+
+	atomic.AddUint64(&blocked, 1)
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case ch <- e:
+			atomic.AddUint64(&published, 1)
+			return true
+		case <-timer.C:
+		}
+	}
+
+	atomic.AddUint64(&dropped, 1)
+	return false
+}
+
+// Events published on the in-process channel
+func Events() <-chan *models.Event {
+	ch, _ := current()
+	return ch
+}
+
+// Return the counters of the in-process channel
+func GetStats() Stats {
+	ch, _ := current()
+	return Stats{
+		Published: atomic.LoadUint64(&published),
+		Blocked:   atomic.LoadUint64(&blocked),
+		Dropped:   atomic.LoadUint64(&dropped),
+		Queued:    len(ch),
+		Capacity:  cap(ch),
+	}
+}
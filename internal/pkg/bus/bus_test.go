@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Circutor/edgex/internal/pkg/correlation/models"
+)
+
+func TestPublishDropsWhenFull(t *testing.T) {
+	Configure(1, 10*time.Millisecond)
+	before := GetStats()
+
+	if !Publish(&models.Event{}) {
+		t.Fatalf("The first event should be published")
+	}
+	if Publish(&models.Event{}) {
+		t.Fatalf("The second event should be dropped")
+	}
+
+	stats := GetStats()
+	if stats.Published-before.Published != 1 || stats.Dropped-before.Dropped != 1 || stats.Blocked-before.Blocked != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats.Queued != 1 || stats.Capacity != 1 {
+		t.Errorf("There should be 1 of 1 events queued, not %d of %d", stats.Queued, stats.Capacity)
+	}
+	<-Events()
+}
+
+func TestPublishWaitsForReceiver(t *testing.T) {
+	Configure(1, time.Second)
+	before := GetStats()
+
+	Publish(&models.Event{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-Events()
+	}()
+	if !Publish(&models.Event{}) {
+		t.Fatalf("The event should be published once the receiver is ready")
+	}
+
+	stats := GetStats()
+	if stats.Published-before.Published != 2 || stats.Blocked-before.Blocked != 1 || stats.Dropped != before.Dropped {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	<-Events()
+}
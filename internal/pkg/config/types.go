@@ -73,6 +73,12 @@ type MessageQueueInfo struct {
 	Protocol string
 	// Indicates the message queue platform being used.
 	Type string
+	// Topic defines the topic of the events, if applicable.
+	Topic string
+	// BufferSize defines the capacity of the in-process channel.
+	BufferSize int
+	// PublishTimeout defines the milliseconds to wait while the in-process channel is full before dropping events.
+	PublishTimeout int
 }
 
 func (m MessageQueueInfo) Uri() string {
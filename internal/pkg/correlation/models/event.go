@@ -54,11 +54,13 @@ func (e Event) MarshalJSON() ([]byte, error) {
 		Modified      int64              `json:"modified,omitempty"`
 		Origin        int64              `json:"origin,omitempty"`
 		Readings      []contract.Reading `json:"readings,omitempty"` // List of readings
+		PushedTo      map[string]int64   `json:"pushedTo,omitempty"`
 	}{
 		Pushed:   e.Pushed,
 		Created:  e.Created,
 		Modified: e.Modified,
 		Origin:   e.Origin,
+		PushedTo: e.PushedTo,
 	}
 
 	// Empty strings are null
@@ -40,6 +40,11 @@ func NewClient(config db.Configuration) (*BoltClient, error) {
 	}
 
 	boltClient := &BoltClient{db: bdb}
+	err = boltClient.ensureEventIndexes()
+	if err != nil {
+		bdb.Close()
+		return nil, err
+	}
 	currentBoltClient = boltClient
 	return boltClient, nil
 }
@@ -15,6 +15,7 @@
 package bolt
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/Circutor/edgex/internal/pkg/db"
@@ -30,9 +31,50 @@ Has functions for interacting with the core data bolt database
 */
 
 const (
-	maxEvents = 50000
+	maxEvents       = 50000
+	deleteBatchSize = 1000
+
+	// Secondary indexes of the events bucket. Every key embeds the creation
+	// timestamp and the event ID, and its value is the event ID
+	eventsByCreated = db.EventsCollection + "ByCreated" // created-id
+	eventsByDevice  = db.EventsCollection + "ByDevice"  // device\x00created-id
+	eventsByPushed  = db.EventsCollection + "ByPushed"  // {0|1}created-id
+
+	// Events pushed by each export registration, those pushed before the
+	// registrations were tracked have an empty registration as they count
+	// as pushed by all of them
+	eventsByRegistration = db.EventsCollection + "ByRegistration" // registration\x00created-id
+
+	// Replay cursor of each registration, the key of its eventsByCreated
+	// index up to which every event has been handled. It is moved when the
+	// registration pushes an event
+	registrationCursors = "registrationCursor" // registration
+
+	// Reading indexes, their values are eventID\x00readingID except for the
+	// ID index that only holds the event ID
+	readingsById         = "readingById"         // readingID
+	readingsByName       = "readingByName"       // name\x00created-readingID
+	readingsByDeviceName = "readingByDeviceName" // device\x00name\x00created-readingID
+
+	unpushedPrefix      = "0"
+	pushedPrefix        = "1"
+	pushedPrefixEnd     = "2"
+	keySeparator        = "\x00"
+	keyRangeEnd         = "\x01"
+	readingRefSeparator = "\x00"
+	maxTimestamp        = 9999999999999
+	pushedByAll         = ""
+
+	// The cursors don't move past the events created in the last minute, events
+	// are stored with a creation time taken before their transaction starts
+	cursorLag = 60 * 1000
+	// Registrations that stop pushing events don't prevent the deletion
+	// of pushed events after a day
+	registrationExpiry = 24 * 60 * 60 * 1000
 )
 
+var eventIndexes = []string{eventsByCreated, eventsByDevice, eventsByPushed, eventsByRegistration, readingsById, readingsByName, readingsByDeviceName}
+
 // ******************************* EVENTS **********************************
 
 // Return all the events
@@ -57,9 +99,23 @@ func (bc *BoltClient) EventsWithLimit(limit int) ([]contract.Event, error) {
 // UnexpectedError - failed to add to database
 // NoValueDescriptor - no existing value descriptor for a reading in the event
 func (bc *BoltClient) AddEvent(e contract.Event) (string, error) {
-	e.Created = db.MakeTimestamp()
-	e.ID = fmt.Sprintf("%013d-", e.Created) + uuid.New().String()
-	e.Modified = e.Created
+	ids, err := bc.AddEvents([]contract.Event{e})
+	if err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// Add several events in a single transaction
+// Return their IDs in the same order
+func (bc *BoltClient) AddEvents(events []contract.Event) ([]string, error) {
+	created := db.MakeTimestamp()
+	added := make([]contract.Event, len(events))
+	ids := make([]string, len(events))
+	for i, e := range events {
+		added[i] = newEvent(e, created)
+		ids[i] = added[i].ID
+	}
 
 	json := jsoniter.ConfigCompatibleWithStandardLibrary
 	err := bc.db.Update(func(tx *bolt.Tx) error {
@@ -68,24 +124,65 @@ func (bc *BoltClient) AddEvent(e contract.Event) (string, error) {
 			return db.ErrUnsupportedDatabase
 		}
 
-		// Deletes older event if its necessary
 		numElements := b.Stats().KeyN
-		if numElements > maxEvents {
-			cursor := b.Cursor()
-			cursor.First()
-			err := cursor.Delete()
+		for _, e := range added {
+			// Deletes older event if its necessary
+			if numElements > maxEvents {
+				cursor := b.Cursor()
+				id, encoded := cursor.First()
+				err := unindexEvent(tx, decodeIndexedFields(id, encoded))
+				if err != nil {
+					return err
+				}
+				err = cursor.Delete()
+				if err != nil {
+					return err
+				}
+			} else {
+				numElements++
+			}
+
+			encoded, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			err = b.Put([]byte(e.ID), encoded)
+			if err != nil {
+				return err
+			}
+			err = indexEvent(tx, e)
 			if err != nil {
 				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
 
-		encoded, err := json.Marshal(e)
-		if err != nil {
-			return err
+// Identify a new event and its readings so they can be queried on their own
+func newEvent(e contract.Event, created int64) contract.Event {
+	e.Created = created
+	e.ID = fmt.Sprintf("%013d-", e.Created) + uuid.New().String()
+	e.Modified = e.Created
+
+	readings := make([]contract.Reading, len(e.Readings))
+	for i, r := range e.Readings {
+		if r.Id == "" {
+			r.Id = uuid.New().String()
 		}
-		return b.Put([]byte(e.ID), encoded)
-	})
-	return e.ID, err
+		if r.Device == "" {
+			r.Device = e.Device
+		}
+		r.Created = e.Created
+		r.Modified = e.Created
+		readings[i] = r
+	}
+	e.Readings = readings
+	return e
 }
 
 // Update an event - do NOT update readings
@@ -94,7 +191,46 @@ func (bc *BoltClient) AddEvent(e contract.Event) (string, error) {
 func (bc *BoltClient) UpdateEvent(e contract.Event) error {
 	e.Modified = db.MakeTimestamp()
 
-	return bc.update(db.EventsCollection, e, e.ID)
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		b, _ := tx.CreateBucketIfNotExists([]byte(db.EventsCollection))
+		if b == nil {
+			return db.ErrUnsupportedDatabase
+		}
+		previous := b.Get([]byte(e.ID))
+		if previous == nil {
+			return db.ErrNotFound
+		}
+		indexed := decodeIndexedFields([]byte(e.ID), previous)
+		err := unindexEvent(tx, indexed)
+		if err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		err = b.Put([]byte(e.ID), encoded)
+		if err != nil {
+			return err
+		}
+		err = indexEvent(tx, e)
+		if err != nil {
+			return err
+		}
+
+		// The cursors of the registrations that have just pushed the event
+		for registration := range e.PushedTo {
+			if _, ok := indexed.PushedTo[registration]; !ok {
+				err = moveRegistrationCursor(tx, registration, e)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
 }
 
 // Get an event by id
@@ -111,105 +247,496 @@ func (bc *BoltClient) EventCount() (int, error) {
 
 // Get the number of events in bolt for the device
 func (bc *BoltClient) EventCountByDeviceId(devid string) (int, error) {
-	bstat := 0
-	err := bc.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(db.EventsCollection))
-		if b == nil {
-			return nil
-		}
-		err := b.ForEach(func(id, encoded []byte) error {
-			value := jsoniter.Get(encoded, "device").ToString()
-			if value == devid {
-				bstat++
-			}
-			return nil
-		})
-		return err
-	})
-	return bstat, err
+	from, to := prefixRange(devid)
+	return bc.countIndexed(eventsByDevice, from, to)
 }
 
 // Delete an event by ID and all of its readings
 // 404 - Event not found
 // 503 - Unexpected problems
 func (bc *BoltClient) DeleteEventById(id string) error {
-	return bc.deleteById(id, db.EventsCollection)
+	// Check if id is a hexstring
+	if !isIdValid(id) {
+		return db.ErrInvalidObjectId
+	}
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		b, _ := tx.CreateBucketIfNotExists([]byte(db.EventsCollection))
+		if b == nil {
+			return db.ErrUnsupportedDatabase
+		}
+		return deleteEvent(tx, b, []byte(id))
+	})
+}
+
+// Delete all the events older than the given age (defined by age = now - created)
+// Return the number of events removed
+func (bc *BoltClient) DeleteEventsOlderThan(age int64) (int, error) {
+	return bc.deleteIndexedEvents(eventsByCreated, nil, ageKey(age), -1)
+}
+
+// Delete all the events of the device
+// Return the number of events removed
+func (bc *BoltClient) DeleteEventsByDevice(devid string) (int, error) {
+	from, to := prefixRange(devid)
+	return bc.deleteIndexedEvents(eventsByDevice, from, to, -1)
+}
+
+// Delete all the events that have been pushed by every registration
+// Return the number of events removed
+func (bc *BoltClient) DeletePushedEvents() (int, error) {
+	return bc.deleteFilteredIndexedEvents(eventsByPushed, []byte(pushedPrefix), []byte(pushedPrefixEnd), -1, pushedByAllFilter)
+}
+
+// Delete the events pushed by every registration older than the given age
+// Return the number of events removed
+func (bc *BoltClient) DeletePushedEventsOlderThan(age int64) (int, error) {
+	to := append([]byte(pushedPrefix), ageKey(age)...)
+	return bc.deleteFilteredIndexedEvents(eventsByPushed, []byte(pushedPrefix), to, -1, pushedByAllFilter)
+}
+
+// Delete up to count events starting from the oldest one
+// If onlyPushed is set the events not pushed yet by every registration are kept
+// Return the number of events removed
+func (bc *BoltClient) DeleteOldestEvents(count int, onlyPushed bool) (int, error) {
+	if count <= 0 {
+		return 0, nil
+	}
+	if onlyPushed {
+		return bc.deleteFilteredIndexedEvents(eventsByPushed, []byte(pushedPrefix), []byte(pushedPrefixEnd), count, pushedByAllFilter)
+	}
+	return bc.deleteIndexedEvents(eventsByCreated, nil, nil, count)
+}
+
+// Get the number of bytes of the database in use, free pages are not counted
+// as the bolt file never shrinks once its pages have been allocated
+func (bc *BoltClient) DatabaseSize() (int64, error) {
+	var size int64
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		stats := bc.db.Stats()
+		free := int64(stats.FreePageN+stats.PendingPageN) * int64(bc.db.Info().PageSize)
+		size = tx.Size() - free
+		return nil
+	})
+	return size, err
 }
 
 // Get a list of events based on the device id and limit
-func (bc *BoltClient) EventsForDeviceLimit(ide string, limit int) ([]contract.Event, error) {
-	return bc.getEvents(func(encoded []byte) bool {
-		value := jsoniter.Get(encoded, "device").ToString()
-		if value == ide {
-			return true
-		}
-		return false
-	}, limit)
+// Sort the events by creation time in the given order
+func (bc *BoltClient) EventsForDeviceLimit(ide string, limit int, order db.SortOrder) ([]contract.Event, error) {
+	from, to := prefixRange(ide)
+	return bc.getIndexedEvents(eventsByDevice, from, to, order, limit)
 }
 
 // Get a list of events based on the device id
 func (bc *BoltClient) EventsForDevice(ide string) ([]contract.Event, error) {
-	return bc.getEvents(func(encoded []byte) bool {
-		value := jsoniter.Get(encoded, "device").ToString()
-		if value == ide {
-			return true
-		}
-		return false
-	}, -1)
+	from, to := prefixRange(ide)
+	return bc.getIndexedEvents(eventsByDevice, from, to, db.OldestFirst, -1)
 }
 
 // Return a list of events whos creation time is between startTime and endTime
-// Limit the number of results by limit
-func (bc *BoltClient) EventsByCreationTime(startTime, endTime int64, limit int) ([]contract.Event, error) {
-	return bc.getEvents(func(encoded []byte) bool {
-		value := jsoniter.Get(encoded, "created").ToInt64()
-		if (value >= startTime) && (value <= endTime) {
-			return true
-		}
-		return false
-	}, limit)
+// Limit the number of results by limit and sort them in the given order
+func (bc *BoltClient) EventsByCreationTime(startTime, endTime int64, limit int, order db.SortOrder) ([]contract.Event, error) {
+	if endTime < startTime {
+		return []contract.Event{}, nil
+	}
+	return bc.getIndexedEvents(eventsByCreated, []byte(timeKey(startTime)), []byte(endTimeKey(endTime)), order, limit)
 }
 
 // Get Events that are older than the given age (defined by age = now - created)
 func (bc *BoltClient) EventsOlderThanAge(age int64) ([]contract.Event, error) {
-	return bc.getEvents(func(encoded []byte) bool {
-		value := jsoniter.Get(encoded, "created").ToInt64()
-		value = (db.MakeTimestamp()) - value
-		if value >= age {
-			return true
-		}
-		return false
-	}, -1)
+	return bc.getIndexedEvents(eventsByCreated, nil, ageKey(age), db.OldestFirst, -1)
 }
 
 // Get all of the events that have been pushed
 func (bc *BoltClient) EventsPushed() ([]contract.Event, error) {
-	return bc.getEvents(func(encoded []byte) bool {
-		value := jsoniter.Get(encoded, "pushed").ToInt64()
-		if value != 0 {
-			return true
-		}
-		return false
-	}, -1)
+	return bc.getIndexedEvents(eventsByPushed, []byte(pushedPrefix), []byte(pushedPrefixEnd), db.OldestFirst, -1)
 }
 
-// Get a list of events based on the device id and limit
+// Get a list of events that have not been pushed, oldest first
 func (bc *BoltClient) EventsUnpushedLimit(limit int) ([]contract.Event, error) {
-	return bc.getEvents(func(encoded []byte) bool {
-		value := jsoniter.Get(encoded, "pushed").ToInt64()
-		if value == 0 {
-			return true
+	return bc.getIndexedEvents(eventsByPushed, []byte(unpushedPrefix), []byte(pushedPrefix), db.OldestFirst, limit)
+}
+
+// Get a list of events that have not been pushed by the given registration, oldest first
+// The events are looked up from the cursor of the registration, those of a registration
+// that has not pushed any event yet from the last minute
+func (bc *BoltClient) EventsUnpushedByRegistrationLimit(registration string, limit int) ([]contract.Event, error) {
+	events := []contract.Event{}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	if limit == 0 {
+		return events, nil
+	}
+
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		cursor, err := getRegistrationCursor(tx, registration)
+		if err != nil {
+			return err
 		}
-		return false
-	}, limit)
+		from := []byte(timeKey(db.MakeTimestamp() - cursorLag))
+		if cursor != nil {
+			from = append([]byte(cursor.Key), 0)
+		}
+
+		b := tx.Bucket([]byte(db.EventsCollection))
+		idx := tx.Bucket([]byte(eventsByCreated))
+		if b == nil || idx == nil {
+			return nil
+		}
+		pushed := tx.Bucket([]byte(eventsByRegistration))
+		err = scanIndex(idx, from, nil, db.OldestFirst, func(key, id []byte) error {
+			if isPushedBy(pushed, registration, key) {
+				return nil
+			}
+			encoded := b.Get(id)
+			if encoded == nil {
+				return nil
+			}
+			event := contract.Event{}
+			err := json.Unmarshal(encoded, &event)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+			if limit > 0 && len(events) >= limit {
+				return ErrLimReached
+			}
+			return nil
+		})
+		if err != nil && err != ErrLimReached {
+			return err
+		}
+		return nil
+	})
+	return events, err
 }
 
 // Delete all of the readings and all of the events
+// The events, their indexes and the registration cursors are removed in a single transaction
 func (bc *BoltClient) ScrubAllEvents() error {
-	bc.scrubAll(db.EventsCollection)
+	buckets := append([]string{db.EventsCollection, registrationCursors}, eventIndexes...)
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range buckets {
+			err := tx.DeleteBucket([]byte(bucket))
+			if err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+			_, err = tx.CreateBucket([]byte(bucket))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type registrationCursor struct {
+	Key  string `json:"key"`  // Events up to this eventsByCreated key have been handled
+	Seen int64  `json:"seen"` // Last time the registration pushed an event
+}
+
+// Get the cursor of a registration, nil if it has not pushed any event
+func getRegistrationCursor(tx *bolt.Tx, registration string) (*registrationCursor, error) {
+	cursors := tx.Bucket([]byte(registrationCursors))
+	if cursors == nil {
+		return nil, nil
+	}
+	encoded := cursors.Get([]byte(registration))
+	if encoded == nil {
+		return nil, nil
+	}
+	cursor := &registrationCursor{}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	err := json.Unmarshal(encoded, cursor)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Move the cursor of a registration that pushed the event over the events it has handled
+// The cursor of a registration pushing its first event starts a minute before the event
+func moveRegistrationCursor(tx *bolt.Tx, registration string, e contract.Event) error {
+	cursor, err := getRegistrationCursor(tx, registration)
+	if err != nil {
+		return err
+	}
+	if cursor == nil {
+		cursor = &registrationCursor{Key: timeKey(e.Created - cursorLag)}
+	}
+
+	idx := tx.Bucket([]byte(eventsByCreated))
+	pushed := tx.Bucket([]byte(eventsByRegistration))
+	if idx != nil {
+		lagKey := []byte(timeKey(db.MakeTimestamp() - cursorLag))
+		err = scanIndex(idx, append([]byte(cursor.Key), 0), lagKey, db.OldestFirst, func(key, id []byte) error {
+			if !isPushedBy(pushed, registration, key) {
+				return ErrLimReached
+			}
+			cursor.Key = string(key)
+			return nil
+		})
+		if err != nil && err != ErrLimReached {
+			return err
+		}
+	}
+
+	cursor.Seen = db.MakeTimestamp()
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	encoded, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+	cursors, err := tx.CreateBucketIfNotExists([]byte(registrationCursors))
+	if err != nil {
+		return err
+	}
+	return cursors.Put([]byte(registration), encoded)
+}
+
+// Check whether the event of an eventsByCreated key has been pushed by the registration
+func isPushedBy(pushed *bolt.Bucket, registration string, key []byte) bool {
+	if pushed == nil {
+		return false
+	}
+	return pushed.Get([]byte(registration+keySeparator+string(key))) != nil ||
+		pushed.Get([]byte(pushedByAll+keySeparator+string(key))) != nil
+}
+
+// Filter of the eventsByPushed keys of the events pushed by every registration
+// seen lately, just the pushed flag is checked if there are none
+func pushedByAllFilter(tx *bolt.Tx) (func(key []byte) bool, error) {
+	cursors := tx.Bucket([]byte(registrationCursors))
+	if cursors == nil {
+		return nil, nil
+	}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	active := map[string]string{}
+	expired := db.MakeTimestamp() - registrationExpiry
+	err := cursors.ForEach(func(registration, encoded []byte) error {
+		cursor := registrationCursor{}
+		err := json.Unmarshal(encoded, &cursor)
+		if err != nil {
+			return err
+		}
+		if cursor.Seen >= expired {
+			active[string(registration)] = cursor.Key
+		}
+		return nil
+	})
+	if err != nil || len(active) == 0 {
+		return nil, err
+	}
+
+	pushed := tx.Bucket([]byte(eventsByRegistration))
+	return func(key []byte) bool {
+		created := key[len(pushedPrefix):]
+		for registration, cursorKey := range active {
+			if string(created) > cursorKey && !isPushedBy(pushed, registration, created) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// ******************************* READINGS **********************************
+
+// Return readings up to the max number specified skipping the first offset ones
+// Sort the readings by creation time in the given order
+func (bc *BoltClient) Readings(offset, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	return bc.getEventReadings(eventsByCreated, nil, nil, order, offset, limit)
+}
+
+// Get a reading by id
+func (bc *BoltClient) ReadingById(id string) (contract.Reading, error) {
+	reading := contract.Reading{}
+	if !isIdValid(id) {
+		return reading, db.ErrInvalidObjectId
+	}
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(db.EventsCollection))
+		idx := tx.Bucket([]byte(readingsById))
+		if b == nil || idx == nil {
+			return db.ErrNotFound
+		}
+		eventId := idx.Get([]byte(id))
+		if eventId == nil {
+			return db.ErrNotFound
+		}
+		encoded := b.Get(eventId)
+		if encoded == nil {
+			return db.ErrNotFound
+		}
+		event := contract.Event{}
+		err := json.Unmarshal(encoded, &event)
+		if err != nil {
+			return err
+		}
+		for _, r := range event.Readings {
+			if r.Id == id {
+				reading = r
+				return nil
+			}
+		}
+		return db.ErrNotFound
+	})
+	return reading, err
+}
+
+// Get the number of readings in bolt
+func (bc *BoltClient) ReadingCount() (int, error) {
+	return bc.count(readingsById)
+}
+
+// Get the readings of a device skipping the first offset ones
+// Sort the readings by creation time in the given order
+func (bc *BoltClient) ReadingsByDevice(id string, offset, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	from, to := prefixRange(id)
+	return bc.getEventReadings(eventsByDevice, from, to, order, offset, limit)
+}
+
+// Get the readings with the given name (value descriptor) skipping the first offset ones
+// Sort the readings by creation time in the given order
+func (bc *BoltClient) ReadingsByName(name string, offset, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	from, to := prefixRange(name)
+	return bc.getIndexedReadings(readingsByName, from, to, order, offset, limit)
+}
+
+// Get the readings of a device with the given name whose creation time is
+// between startTime and endTime skipping the first offset ones
+// Sort the readings by creation time in the given order
+func (bc *BoltClient) ReadingsByDeviceAndName(id, name string, startTime, endTime int64, offset, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	if endTime < startTime {
+		return []contract.Reading{}, nil
+	}
+	prefix := id + keySeparator + name + keySeparator
+	from := []byte(prefix + timeKey(startTime))
+	to := []byte(prefix + endTimeKey(endTime))
+	return bc.getIndexedReadings(readingsByDeviceName, from, to, order, offset, limit)
+}
+
+// Get the readings whose creation time is between startTime and endTime skipping the first offset ones
+// Sort the readings by creation time in the given order
+func (bc *BoltClient) ReadingsByCreationTime(startTime, endTime int64, offset, limit int, order db.SortOrder) ([]contract.Reading, error) {
+	if endTime < startTime {
+		return []contract.Reading{}, nil
+	}
+	return bc.getEventReadings(eventsByCreated, []byte(timeKey(startTime)), []byte(endTimeKey(endTime)), order, offset, limit)
+}
+
+// Page of readings being collected by a query
+type readingPage struct {
+	offset   int
+	limit    int
+	readings []contract.Reading
+}
+
+// Add a reading to the page once the offset has been skipped
+// Return ErrLimReached when the page is full
+func (p *readingPage) add(r contract.Reading) error {
+	if p.offset > 0 {
+		p.offset--
+		return nil
+	}
+	p.readings = append(p.readings, r)
+	if p.limit > 0 && len(p.readings) >= p.limit {
+		return ErrLimReached
+	}
 	return nil
 }
 
+// Get the readings of the events referenced by one of the event indexes
+func (bc *BoltClient) getEventReadings(index string, from, to []byte, order db.SortOrder, offset, limit int) ([]contract.Reading, error) {
+	page := readingPage{offset: offset, limit: limit, readings: []contract.Reading{}}
+	if limit == 0 {
+		return page.readings, nil
+	}
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(db.EventsCollection))
+		idx := tx.Bucket([]byte(index))
+		if b == nil || idx == nil {
+			return nil
+		}
+		err := scanIndex(idx, from, to, order, func(key, id []byte) error {
+			encoded := b.Get(id)
+			if encoded == nil {
+				return nil
+			}
+			event := contract.Event{}
+			err := json.Unmarshal(encoded, &event)
+			if err != nil {
+				return err
+			}
+			for _, r := range event.Readings {
+				err = page.add(r)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err == ErrLimReached {
+			return nil
+		}
+		return err
+	})
+	return page.readings, err
+}
+
+// Get the readings referenced by one of the reading indexes
+func (bc *BoltClient) getIndexedReadings(index string, from, to []byte, order db.SortOrder, offset, limit int) ([]contract.Reading, error) {
+	page := readingPage{offset: offset, limit: limit, readings: []contract.Reading{}}
+	if limit == 0 {
+		return page.readings, nil
+	}
+
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(db.EventsCollection))
+		idx := tx.Bucket([]byte(index))
+		if b == nil || idx == nil {
+			return nil
+		}
+		// Consecutive readings usually belong to the same event
+		event := contract.Event{}
+		err := scanIndex(idx, from, to, order, func(key, ref []byte) error {
+			parts := bytes.SplitN(ref, []byte(readingRefSeparator), 2)
+			if len(parts) != 2 {
+				return nil
+			}
+			eventId, readingId := string(parts[0]), string(parts[1])
+			if event.ID != eventId {
+				encoded := b.Get(parts[0])
+				if encoded == nil {
+					return nil
+				}
+				event = contract.Event{}
+				err := json.Unmarshal(encoded, &event)
+				if err != nil {
+					return err
+				}
+				event.ID = eventId
+			}
+			for _, r := range event.Readings {
+				if r.Id == readingId {
+					return page.add(r)
+				}
+			}
+			return nil
+		})
+		if err == ErrLimReached {
+			return nil
+		}
+		return err
+	})
+	return page.readings, err
+}
+
 // Get events for the passed check
 func (bc *BoltClient) getEvents(fn func(encoded []byte) bool, limit int) ([]contract.Event, error) {
 	events := []contract.Event{}
@@ -250,3 +777,399 @@ func (bc *BoltClient) getEvents(fn func(encoded []byte) bool, limit int) ([]cont
 	})
 	return events, err
 }
+
+// Get events using one of the secondary indexes
+// Only the index keys in the range [from, to) are visited
+func (bc *BoltClient) getIndexedEvents(index string, from, to []byte, order db.SortOrder, limit int) ([]contract.Event, error) {
+	return bc.getFilteredIndexedEvents(index, from, to, order, limit, nil)
+}
+
+// Get the events of an index range accepted by fn, a nil fn accepts all of them
+func (bc *BoltClient) getFilteredIndexedEvents(index string, from, to []byte, order db.SortOrder, limit int, fn func(encoded []byte) bool) ([]contract.Event, error) {
+	events := []contract.Event{}
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+
+	// Check if limit is not 0
+	if limit == 0 {
+		return events, nil
+	}
+
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(db.EventsCollection))
+		idx := tx.Bucket([]byte(index))
+		if b == nil || idx == nil {
+			return nil
+		}
+		err := scanIndex(idx, from, to, order, func(key, id []byte) error {
+			encoded := b.Get(id)
+			if encoded == nil || (fn != nil && !fn(encoded)) {
+				return nil
+			}
+			event := contract.Event{}
+			err := json.Unmarshal(encoded, &event)
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+			if limit > 0 && len(events) >= limit {
+				return ErrLimReached
+			}
+			return nil
+		})
+		if err == ErrLimReached {
+			return nil
+		}
+		return err
+	})
+	return events, err
+}
+
+// Count the keys of an index in the range [from, to)
+func (bc *BoltClient) countIndexed(index string, from, to []byte) (int, error) {
+	cnt := 0
+	err := bc.db.View(func(tx *bolt.Tx) error {
+		idx := tx.Bucket([]byte(index))
+		if idx == nil {
+			return nil
+		}
+		return scanIndex(idx, from, to, db.OldestFirst, func(key, id []byte) error {
+			cnt++
+			return nil
+		})
+	})
+	return cnt, err
+}
+
+// Walk the index keys in the range [from, to) calling fn with each key and event ID
+// A nil from or to leaves that side of the range open
+func scanIndex(idx *bolt.Bucket, from, to []byte, order db.SortOrder, fn func(key, id []byte) error) error {
+	c := idx.Cursor()
+
+	if order == db.NewestFirst {
+		var k, v []byte
+		if to == nil {
+			k, v = c.Last()
+		} else if k, v = c.Seek(to); k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil && bytes.Compare(k, from) >= 0; k, v = c.Prev() {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var k, v []byte
+	if from == nil {
+		k, v = c.First()
+	} else {
+		k, v = c.Seek(from)
+	}
+	for ; k != nil && (to == nil || bytes.Compare(k, to) < 0); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete the events referenced by the index keys in the range [from, to)
+// up to limit events (-1 for no limit)
+// The events are removed in batches, each one in its own transaction, so
+// big deletions don't hold every dirty page in memory until the commit
+func (bc *BoltClient) deleteIndexedEvents(index string, from, to []byte, limit int) (int, error) {
+	return bc.deleteFilteredIndexedEvents(index, from, to, limit, nil)
+}
+
+// Delete the events of an index range whose keys are accepted by the function
+// built by filter on each transaction, a nil filter or function accepts all of them
+func (bc *BoltClient) deleteFilteredIndexedEvents(index string, from, to []byte, limit int, filter func(tx *bolt.Tx) (func(key []byte) bool, error)) (int, error) {
+	count := 0
+	for {
+		batch := deleteBatchSize
+		if limit >= 0 && limit-count < batch {
+			batch = limit - count
+		}
+		if batch == 0 {
+			return count, nil
+		}
+
+		found, deleted := 0, 0
+		err := bc.db.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(db.EventsCollection))
+			idx := tx.Bucket([]byte(index))
+			if b == nil || idx == nil {
+				return nil
+			}
+			var accept func(key []byte) bool
+			if filter != nil {
+				var err error
+				accept, err = filter(tx)
+				if err != nil {
+					return err
+				}
+			}
+
+			// The cursor can't be used while its bucket is modified, so
+			// collect the batch before deleting it
+			var keys, ids [][]byte
+			var last []byte
+			err := scanIndex(idx, from, to, db.OldestFirst, func(key, id []byte) error {
+				last = key
+				if accept != nil && !accept(key) {
+					return nil
+				}
+				keys = append(keys, append([]byte{}, key...))
+				ids = append(ids, append([]byte{}, id...))
+				if len(keys) >= batch {
+					return ErrLimReached
+				}
+				return nil
+			})
+			if err != nil && err != ErrLimReached {
+				return err
+			}
+			found = len(keys)
+			// The next batch goes on after the keys kept
+			if last != nil {
+				from = append(append([]byte{}, last...), 0)
+			}
+
+			for i, id := range ids {
+				err = deleteEvent(tx, b, id)
+				if err == db.ErrNotFound {
+					// Dangling index entry, drop it
+					err = idx.Delete(keys[i])
+				} else if err == nil {
+					deleted++
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+		count += deleted
+		if found < batch {
+			return count, nil
+		}
+	}
+}
+
+// Delete an event and its index entries
+func deleteEvent(tx *bolt.Tx, b *bolt.Bucket, id []byte) error {
+	encoded := b.Get(id)
+	if encoded == nil {
+		return db.ErrNotFound
+	}
+	err := unindexEvent(tx, decodeIndexedFields(id, encoded))
+	if err != nil {
+		return err
+	}
+	return b.Delete(id)
+}
+
+// Add the index entries of an event and its readings
+func indexEvent(tx *bolt.Tx, e contract.Event) error {
+	for _, entry := range eventIndexEntries(e) {
+		b, err := tx.CreateBucketIfNotExists([]byte(entry.index))
+		if err != nil {
+			return err
+		}
+		err = b.Put(entry.key, entry.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the index entries of an event and its readings
+func unindexEvent(tx *bolt.Tx, e contract.Event) error {
+	for _, entry := range eventIndexEntries(e) {
+		b := tx.Bucket([]byte(entry.index))
+		if b == nil {
+			continue
+		}
+		err := b.Delete(entry.key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type indexEntry struct {
+	index string
+	key   []byte
+	value []byte
+}
+
+// Build the entries of the event and its readings in every index
+func eventIndexEntries(e contract.Event) []indexEntry {
+	created := timeKey(e.Created)
+	suffix := created + "-" + e.ID
+
+	pushed := unpushedPrefix
+	if e.Pushed != 0 {
+		pushed = pushedPrefix
+	}
+
+	entries := []indexEntry{
+		{eventsByCreated, []byte(suffix), []byte(e.ID)},
+		{eventsByDevice, []byte(e.Device + keySeparator + suffix), []byte(e.ID)},
+		{eventsByPushed, []byte(pushed + suffix), []byte(e.ID)},
+	}
+	if e.Pushed != 0 && len(e.PushedTo) == 0 {
+		entries = append(entries, indexEntry{eventsByRegistration, []byte(pushedByAll + keySeparator + suffix), []byte(e.ID)})
+	}
+	for registration := range e.PushedTo {
+		entries = append(entries, indexEntry{eventsByRegistration, []byte(registration + keySeparator + suffix), []byte(e.ID)})
+	}
+	for _, r := range e.Readings {
+		if r.Id == "" {
+			continue
+		}
+		ref := []byte(e.ID + readingRefSeparator + r.Id)
+		suffix := created + "-" + r.Id
+		entries = append(entries,
+			indexEntry{readingsById, []byte(r.Id), []byte(e.ID)},
+			indexEntry{readingsByName, []byte(r.Name + keySeparator + suffix), ref},
+			indexEntry{readingsByDeviceName, []byte(e.Device + keySeparator + r.Name + keySeparator + suffix), ref})
+	}
+	return entries
+}
+
+// Decode only the event fields needed to build its index keys
+func decodeIndexedFields(id, encoded []byte) contract.Event {
+	e := contract.Event{
+		ID:      string(id),
+		Device:  jsoniter.Get(encoded, "device").ToString(),
+		Created: jsoniter.Get(encoded, "created").ToInt64(),
+		Pushed:  jsoniter.Get(encoded, "pushed").ToInt64(),
+	}
+	for _, registration := range jsoniter.Get(encoded, "pushedTo").Keys() {
+		if e.PushedTo == nil {
+			e.PushedTo = make(map[string]int64)
+		}
+		e.PushedTo[registration] = jsoniter.Get(encoded, "pushedTo", registration).ToInt64()
+	}
+	readings := jsoniter.Get(encoded, "readings")
+	for i := 0; i < readings.Size(); i++ {
+		e.Readings = append(e.Readings, contract.Reading{
+			Id:   readings.Get(i, "id").ToString(),
+			Name: readings.Get(i, "name").ToString(),
+		})
+	}
+	return e
+}
+
+// Index key range covering the keys made of the given prefix and a separator
+func prefixRange(prefix string) ([]byte, []byte) {
+	return []byte(prefix + keySeparator), []byte(prefix + keyRangeEnd)
+}
+
+// Index key bounding the events created at least age milliseconds ago
+func ageKey(age int64) []byte {
+	return []byte(timeKey(db.MakeTimestamp() - age + 1))
+}
+
+// Index key bounding the events created up to end (included)
+func endTimeKey(end int64) string {
+	if end >= maxTimestamp {
+		// ':' sorts right after the digits
+		return ":"
+	}
+	return timeKey(end + 1)
+}
+
+// Fixed width timestamp so index keys sort chronologically
+func timeKey(t int64) string {
+	if t < 0 {
+		t = 0
+	}
+	return fmt.Sprintf("%013d", t)
+}
+
+// Build the event and reading indexes of databases created before they existed
+func (bc *BoltClient) ensureEventIndexes() error {
+	return bc.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(db.EventsCollection))
+		if b == nil {
+			return nil
+		}
+
+		complete := true
+		for _, index := range eventIndexes {
+			if tx.Bucket([]byte(index)) == nil {
+				complete = false
+			}
+			_, err := tx.CreateBucketIfNotExists([]byte(index))
+			if err != nil {
+				return err
+			}
+		}
+		if complete {
+			return nil
+		}
+
+		// Readings stored before the indexes existed have no ID, the events are rewritten once iterated
+		var legacy [][]byte
+		err := b.ForEach(func(id, encoded []byte) error {
+			e := decodeIndexedFields(id, encoded)
+			for _, r := range e.Readings {
+				if r.Id == "" {
+					legacy = append(legacy, append([]byte{}, id...))
+					return nil
+				}
+			}
+			return indexEvent(tx, e)
+		})
+		if err != nil {
+			return err
+		}
+
+		json := jsoniter.ConfigCompatibleWithStandardLibrary
+		for _, id := range legacy {
+			e := contract.Event{}
+			err := json.Unmarshal(b.Get(id), &e)
+			if err != nil {
+				return err
+			}
+			e.ID = string(id)
+			for i, r := range e.Readings {
+				if r.Id == "" {
+					r.Id = uuid.New().String()
+				}
+				if r.Device == "" {
+					r.Device = e.Device
+				}
+				if r.Created == 0 {
+					r.Created = e.Created
+					r.Modified = e.Created
+				}
+				e.Readings[i] = r
+			}
+
+			encoded, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			err = b.Put(id, encoded)
+			if err != nil {
+				return err
+			}
+			err = indexEvent(tx, e)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,208 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Circutor/edgex/internal/pkg/db"
+	contract "github.com/Circutor/edgex/pkg/models"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Events as stored before the indexes existed, the readings have no ID
+var legacyEvents = map[string]string{
+	"1577836800000-a": `{"id":"1577836800000-a","device":"meter1","created":1577836800000,"modified":1577836800000,"pushed":1577836801000,` +
+		`"readings":[{"name":"VOLTAGE","value":"230"},{"name":"CURRENT","value":"5"}]}`,
+	"1577836860000-b": `{"id":"1577836860000-b","device":"meter1","created":1577836860000,"modified":1577836860000,` +
+		`"readings":[{"name":"VOLTAGE","value":"231"}]}`,
+}
+
+func TestLegacyEventsMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt")
+	if err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "coredata.db")
+
+	legacy, err := bolt.Open(file, 0600, nil)
+	if err != nil {
+		t.Fatalf("Could not open BoltDB: %v", err)
+	}
+	err = legacy.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(db.EventsCollection))
+		if err != nil {
+			return err
+		}
+		for id, encoded := range legacyEvents {
+			if err := b.Put([]byte(id), []byte(encoded)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	legacy.Close()
+	if err != nil {
+		t.Fatalf("Error storing legacy events: %v", err)
+	}
+
+	client, err := NewClient(db.Configuration{DatabaseName: file})
+	if err != nil {
+		t.Fatalf("Could not open BoltDB: %v", err)
+	}
+	defer client.CloseSession()
+
+	count, err := client.ReadingCount()
+	if err != nil || count != 3 {
+		t.Fatalf("There should be 3 readings, not %d: %v", count, err)
+	}
+
+	readings, err := client.ReadingsByDeviceAndName("meter1", "VOLTAGE", 0, db.MakeTimestamp(), 0, -1, db.OldestFirst)
+	if err != nil || len(readings) != 2 {
+		t.Fatalf("There should be 2 voltage readings of meter1, not %d: %v", len(readings), err)
+	}
+	if readings[0].Value != "230" || readings[1].Value != "231" {
+		t.Errorf("The readings should be sorted by creation time")
+	}
+
+	reading, err := client.ReadingById(readings[0].Id)
+	if err != nil || reading.Device != "meter1" || reading.Created != 1577836800000 {
+		t.Errorf("The legacy reading should be found by its new ID: %v %v", reading, err)
+	}
+
+	readings, err = client.ReadingsByName("CURRENT", 0, -1, db.OldestFirst)
+	if err != nil || len(readings) != 1 {
+		t.Errorf("There should be 1 current reading, not %d: %v", len(readings), err)
+	}
+
+	event, err := client.EventById("1577836800000-a")
+	if err != nil || event.Pushed != 1577836801000 {
+		t.Errorf("The rest of the event should be kept: %v %v", event, err)
+	}
+}
+
+func TestEventsPushedByRegistration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt")
+	if err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := NewClient(db.Configuration{DatabaseName: filepath.Join(dir, "coredata.db")})
+	if err != nil {
+		t.Fatalf("Could not open BoltDB: %v", err)
+	}
+	defer client.CloseSession()
+
+	// Events created an hour ago, pushed by both registrations, by the first
+	// one, before the registrations were tracked and not pushed
+	old := db.MakeTimestamp() - 3600000
+	pushedTo := []map[string]int64{{"reg1": old, "reg2": old}, {"reg1": old}, nil, nil}
+	var ids []string
+	for i, to := range pushedTo {
+		id, err := client.AddEvent(contract.Event{Device: "meter1"})
+		if err != nil {
+			t.Fatalf("Error adding event: %v", err)
+		}
+		e, _ := client.EventById(id)
+		e.Created = old + int64(i)
+		e.PushedTo = to
+		if i < 3 {
+			e.Pushed = old
+		}
+		if err = client.UpdateEvent(e); err != nil {
+			t.Fatalf("Error updating event: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	events, err := client.EventsUnpushedByRegistrationLimit("reg1", 10)
+	if err != nil || len(events) != 1 || events[0].ID != ids[3] {
+		t.Fatalf("Only the unpushed event should be replayed to reg1: %v %v", events, err)
+	}
+	events, err = client.EventsUnpushedByRegistrationLimit("reg2", 10)
+	if err != nil || len(events) != 2 || events[0].ID != ids[1] || events[1].ID != ids[3] {
+		t.Fatalf("The events not pushed by reg2 should be replayed: %v %v", events, err)
+	}
+
+	count, err := client.DeletePushedEvents()
+	if err != nil || count != 2 {
+		t.Fatalf("Only the events pushed by every registration should be deleted, not %d: %v", count, err)
+	}
+	if _, err = client.EventById(ids[1]); err != nil {
+		t.Errorf("The event not pushed by reg2 should be kept: %v", err)
+	}
+
+	// The cursor skips the events handled, later pushes are still seen
+	e, _ := client.EventById(ids[3])
+	e.Pushed = old
+	e.PushedTo = map[string]int64{"reg2": old}
+	if err = client.UpdateEvent(e); err != nil {
+		t.Fatalf("Error updating event: %v", err)
+	}
+	events, err = client.EventsUnpushedByRegistrationLimit("reg2", 10)
+	if err != nil || len(events) != 1 || events[0].ID != ids[1] {
+		t.Errorf("Only the event not pushed by reg2 should be replayed: %v %v", events, err)
+	}
+	count, err = client.DeleteOldestEvents(10, true)
+	if err != nil || count != 0 {
+		t.Errorf("No event is pushed by every registration, %d deleted: %v", count, err)
+	}
+
+	// A registration that has not pushed any event does not replay the history,
+	// and looking for its events does not keep the others from being deleted
+	events, err = client.EventsUnpushedByRegistrationLimit("reg3", 10)
+	if err != nil || len(events) != 0 {
+		t.Errorf("The events older than the registration should not be replayed: %v %v", events, err)
+	}
+	e, _ = client.EventById(ids[1])
+	e.PushedTo = map[string]int64{"reg1": old, "reg2": old}
+	if err = client.UpdateEvent(e); err != nil {
+		t.Fatalf("Error updating event: %v", err)
+	}
+	count, err = client.DeleteOldestEvents(10, true)
+	if err != nil || count != 1 {
+		t.Errorf("The event pushed by reg1 and reg2 should be deleted, not %d: %v", count, err)
+	}
+}
+
+func TestScrubAllEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bolt")
+	if err != nil {
+		t.Fatalf("Error creating directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, err := NewClient(db.Configuration{DatabaseName: filepath.Join(dir, "coredata.db")})
+	if err != nil {
+		t.Fatalf("Could not open BoltDB: %v", err)
+	}
+	defer client.CloseSession()
+
+	id, err := client.AddEvent(contract.Event{Device: "meter1", Readings: []contract.Reading{{Name: "POWER", Value: "1"}}})
+	if err != nil {
+		t.Fatalf("Error adding event: %v", err)
+	}
+	e, _ := client.EventById(id)
+	e.PushedTo = map[string]int64{"reg1": db.MakeTimestamp()}
+	client.UpdateEvent(e)
+
+	if err = client.ScrubAllEvents(); err != nil {
+		t.Fatalf("Error removing all events: %v", err)
+	}
+	events, _ := client.EventCount()
+	readings, _ := client.ReadingCount()
+	devices, _ := client.EventCountByDeviceId("meter1")
+	if events != 0 || readings != 0 || devices != 0 {
+		t.Errorf("No event or reading should be left: %d events, %d readings, %d by device", events, readings, devices)
+	}
+}
@@ -54,6 +54,14 @@ var (
 	ErrNameEmpty           = errors.New("Name is required")
 )
 
+// Order in which time indexed queries return their results
+type SortOrder int
+
+const (
+	OldestFirst SortOrder = iota
+	NewestFirst
+)
+
 type Configuration struct {
 	DbType       string
 	Host         string
@@ -41,6 +41,18 @@ type Addressable struct {
 	User       string        `bson:"user"`      // User id for authentication
 	Password   string        `bson:"password"`  // Password of the user for authentication for the addressable
 	Topic      string        `bson:"topic"`     // Topic for message bus addressables
+	// Authentication and options of the HTTP, TLS and MQTT connections
+	Certificate        string               `bson:"certificate,omitempty"`
+	Key                string               `bson:"key,omitempty"`
+	CACertificate      string               `bson:"caCertificate,omitempty"`
+	InsecureSkipVerify bool                 `bson:"insecureSkipVerify,omitempty"`
+	ServerName         string               `bson:"serverName,omitempty"`
+	AuthType           string               `bson:"authType,omitempty"`
+	AuthHeader         string               `bson:"authHeader,omitempty"`
+	Token              string               `bson:"token,omitempty"`
+	Headers            map[string]string    `bson:"headers,omitempty"`
+	Timeout            int                  `bson:"timeout,omitempty"`
+	MQTT               contract.MQTTOptions `bson:"mqtt"`
 }
 
 func (a *Addressable) ToContract() (c contract.Addressable) {
@@ -64,6 +76,17 @@ func (a *Addressable) ToContract() (c contract.Addressable) {
 	c.User = a.User
 	c.Password = a.Password
 	c.Topic = a.Topic
+	c.Certificate = a.Certificate
+	c.Key = a.Key
+	c.CACertificate = a.CACertificate
+	c.InsecureSkipVerify = a.InsecureSkipVerify
+	c.ServerName = a.ServerName
+	c.AuthType = a.AuthType
+	c.AuthHeader = a.AuthHeader
+	c.Token = a.Token
+	c.Headers = a.Headers
+	c.Timeout = a.Timeout
+	c.MQTT = a.MQTT
 
 	return
 }
@@ -86,6 +109,17 @@ func (a *Addressable) FromContract(from contract.Addressable) (id string, err er
 	a.User = from.User
 	a.Password = from.Password
 	a.Topic = from.Topic
+	a.Certificate = from.Certificate
+	a.Key = from.Key
+	a.CACertificate = from.CACertificate
+	a.InsecureSkipVerify = from.InsecureSkipVerify
+	a.ServerName = from.ServerName
+	a.AuthType = from.AuthType
+	a.AuthHeader = from.AuthHeader
+	a.Token = from.Token
+	a.Headers = from.Headers
+	a.Timeout = from.Timeout
+	a.MQTT = from.MQTT
 
 	id = toContractId(a.Id, a.Uuid)
 	return
@@ -21,8 +21,13 @@ import (
 )
 
 type Filter struct {
-	DeviceIDs          []string `bson:"deviceIdentifiers,omitempty"`
-	ValueDescriptorIDs []string `bson:"valueDescriptorIdentifiers,omitempty"`
+	DeviceIDs          []string                  `bson:"deviceIdentifiers,omitempty"`
+	ValueDescriptorIDs []string                  `bson:"valueDescriptorIdentifiers,omitempty"`
+	DevicePattern      string                    `bson:"devicePattern,omitempty"`
+	ReadingPattern     string                    `bson:"readingPattern,omitempty"`
+	DeviceLabels       []string                  `bson:"deviceLabels,omitempty"`
+	Values             []contract.ValueCondition `bson:"values,omitempty"`
+	Deadbands          []contract.Deadband       `bson:"deadbands,omitempty"`
 }
 
 type EncryptionDetails struct {
@@ -45,6 +50,12 @@ type Registration struct {
 	Compression string
 	Enable      bool
 	Destination string
+	// Options of the formats, aggregation and batches
+	CSV             contract.CSVOptions  `bson:"csv"`
+	Template        string               `bson:"template,omitempty"`
+	Aggregation     contract.Aggregation `bson:"aggregation"`
+	Batch           contract.Batch       `bson:"batch"`
+	DexmaParameters map[string]int       `bson:"dexmaParameters,omitempty"`
 }
 
 func (r *Registration) ToContract() (c contract.Registration) {
@@ -63,6 +74,11 @@ func (r *Registration) ToContract() (c contract.Registration) {
 
 	c.Filter.DeviceIDs = r.Filter.DeviceIDs
 	c.Filter.ValueDescriptorIDs = r.Filter.ValueDescriptorIDs
+	c.Filter.DevicePattern = r.Filter.DevicePattern
+	c.Filter.ReadingPattern = r.Filter.ReadingPattern
+	c.Filter.DeviceLabels = r.Filter.DeviceLabels
+	c.Filter.Values = r.Filter.Values
+	c.Filter.Deadbands = r.Filter.Deadbands
 
 	c.Encryption.Algo = r.Encryption.Algo
 	c.Encryption.Key = r.Encryption.Key
@@ -71,6 +87,11 @@ func (r *Registration) ToContract() (c contract.Registration) {
 	c.Compression = r.Compression
 	c.Enable = r.Enable
 	c.Destination = r.Destination
+	c.CSV = r.CSV
+	c.Template = r.Template
+	c.Aggregation = r.Aggregation
+	c.Batch = r.Batch
+	c.DexmaParameters = r.DexmaParameters
 
 	return
 }
@@ -96,6 +117,11 @@ func (r *Registration) FromContract(from contract.Registration) (id string, err
 
 	r.Filter.DeviceIDs = from.Filter.DeviceIDs
 	r.Filter.ValueDescriptorIDs = from.Filter.ValueDescriptorIDs
+	r.Filter.DevicePattern = from.Filter.DevicePattern
+	r.Filter.ReadingPattern = from.Filter.ReadingPattern
+	r.Filter.DeviceLabels = from.Filter.DeviceLabels
+	r.Filter.Values = from.Filter.Values
+	r.Filter.Deadbands = from.Filter.Deadbands
 
 	r.Encryption.Algo = from.Encryption.Algo
 	r.Encryption.Key = from.Encryption.Key
@@ -104,6 +130,11 @@ func (r *Registration) FromContract(from contract.Registration) (id string, err
 	r.Compression = from.Compression
 	r.Enable = from.Enable
 	r.Destination = from.Destination
+	r.CSV = from.CSV
+	r.Template = from.Template
+	r.Aggregation = from.Aggregation
+	r.Batch = from.Batch
+	r.DexmaParameters = from.DexmaParameters
 
 	id = toContractId(r.ID, r.Uuid)
 	return
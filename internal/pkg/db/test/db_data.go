@@ -111,28 +111,28 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 		t.Fatalf("Event should not be found")
 	}
 
-	events, err = db.EventsForDeviceLimit("name1", 10)
+	events, err = db.EventsForDeviceLimit("name1", 10, dbp.OldestFirst)
 	if err != nil {
 		t.Fatalf("Error getting EventsForDeviceLimit: %v", err)
 	}
 	if len(events) != 2 {
 		t.Fatalf("There should be 2 events, not %d", len(events))
 	}
-	events, err = db.EventsForDeviceLimit("name1", 1)
+	events, err = db.EventsForDeviceLimit("name1", 1, dbp.OldestFirst)
 	if err != nil {
 		t.Fatalf("Error getting EventsForDeviceLimit: %v", err)
 	}
 	if len(events) != 1 {
 		t.Fatalf("There should be 1 events, not %d", len(events))
 	}
-	events, err = db.EventsForDeviceLimit("name20", 10)
+	events, err = db.EventsForDeviceLimit("name20", 10, dbp.OldestFirst)
 	if err != nil {
 		t.Fatalf("Error getting EventsForDeviceLimit: %v", err)
 	}
 	if len(events) != 1 {
 		t.Fatalf("There should be 1 events, not %d", len(events))
 	}
-	events, err = db.EventsForDeviceLimit("name", 10)
+	events, err = db.EventsForDeviceLimit("name", 10, dbp.OldestFirst)
 	if err != nil {
 		t.Fatalf("Error getting EventsForDeviceLimit: %v", err)
 	}
@@ -162,14 +162,14 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 		t.Fatalf("There should be 0 events, not %d", len(events))
 	}
 
-	events, err = db.EventsByCreationTime(beforeTime, afterTime, 200)
+	events, err = db.EventsByCreationTime(beforeTime, afterTime, 200, dbp.OldestFirst)
 	if err != nil {
 		t.Fatalf("Error getting EventsByCreationTime: %v", err)
 	}
 	if len(events) != 110 {
 		t.Fatalf("There should be 110 events, not %d", len(events))
 	}
-	events, err = db.EventsByCreationTime(beforeTime, afterTime, 100)
+	events, err = db.EventsByCreationTime(beforeTime, afterTime, 100, dbp.OldestFirst)
 	if err != nil {
 		t.Fatalf("Error getting EventsByCreationTime: %v", err)
 	}
@@ -177,6 +177,62 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 		t.Fatalf("There should be 100 events, not %d", len(events))
 	}
 
+	events, err = db.EventsByCreationTime(beforeTime, afterTime, 10, dbp.NewestFirst)
+	if err != nil {
+		t.Fatalf("Error getting EventsByCreationTime: %v", err)
+	}
+	if len(events) != 10 {
+		t.Fatalf("There should be 10 events, not %d", len(events))
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Created > events[i-1].Created {
+			t.Fatalf("Events are not sorted newest first")
+		}
+	}
+
+	events, err = db.EventsForDeviceLimit("name1", 1, dbp.NewestFirst)
+	if err != nil {
+		t.Fatalf("Error getting EventsForDeviceLimit: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("There should be 1 events, not %d", len(events))
+	}
+
+	events, err = db.EventsUnpushedLimit(200)
+	if err != nil {
+		t.Fatalf("Error getting EventsUnpushedLimit: %v", err)
+	}
+	if len(events) != 100 {
+		t.Fatalf("There should be 100 events, not %d", len(events))
+	}
+
+	e4 := events[0]
+	e4.PushedTo = map[string]int64{"registration1": dbp.MakeTimestamp()}
+	err = db.UpdateEvent(e4)
+	if err != nil {
+		t.Fatalf("Error updating event %v", err)
+	}
+	events, err = db.EventsUnpushedByRegistrationLimit("registration1", 200)
+	if err != nil {
+		t.Fatalf("Error getting EventsUnpushedByRegistrationLimit: %v", err)
+	}
+	// The events pushed without registration count as pushed by all of them
+	if len(events) != 99 {
+		t.Fatalf("There should be 99 events, not %d", len(events))
+	}
+	for _, e := range events {
+		if e.ID == e4.ID {
+			t.Fatalf("Event pushed by the registration should not be returned")
+		}
+	}
+	events, err = db.EventsUnpushedByRegistrationLimit("registration2", 5)
+	if err != nil {
+		t.Fatalf("Error getting EventsUnpushedByRegistrationLimit: %v", err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("There should be 5 events, not %d", len(events))
+	}
+
 	events, err = db.EventsOlderThanAge(0)
 	if err != nil {
 		t.Fatalf("Error getting EventsOlderThanAge: %v", err)
@@ -194,7 +250,7 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 
 	events, err = db.EventsPushed()
 	if err != nil {
-		t.Fatalf("Error getting EventsOlderThanAge: %v", err)
+		t.Fatalf("Error getting EventsPushed: %v", err)
 	}
 	if len(events) != 10 {
 		t.Fatalf("There should be 10 events, not %d", len(events))
@@ -214,6 +270,20 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 	if e2.Device != e.Device {
 		t.Fatalf("Did not update event correctly: %s %s", e.Device, e2.Device)
 	}
+	count, err = db.EventCountByDeviceId("name")
+	if err != nil {
+		t.Fatalf("Error getting events count:  %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("There should be 1 events instead of %d", count)
+	}
+	events, err = db.EventsPushed()
+	if err != nil {
+		t.Fatalf("Error getting EventsPushed: %v", err)
+	}
+	if len(events) != 9 {
+		t.Fatalf("There should be 9 events, not %d", len(events))
+	}
 
 	err = db.DeleteEventById("INVALID")
 	if err == nil {
@@ -224,12 +294,105 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 	if err != nil {
 		t.Fatalf("Event should be deleted: %v", err)
 	}
+	count, err = db.EventCountByDeviceId("name")
+	if err != nil {
+		t.Fatalf("Error getting events count:  %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("There should be 0 events instead of %d", count)
+	}
 
 	err = db.UpdateEvent(e)
 	if err == nil {
 		t.Fatalf("Update should return error")
 	}
 
+	count, err = db.DeletePushedEvents()
+	if err != nil {
+		t.Fatalf("Error deleting pushed events: %v", err)
+	}
+	if count != 9 {
+		t.Fatalf("There should be 9 events deleted, not %d", count)
+	}
+
+	count, err = db.DeleteEventsByDevice("name1")
+	if err != nil {
+		t.Fatalf("Error deleting events by device: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("There should be 1 events deleted, not %d", count)
+	}
+
+	count, err = db.DeleteEventsOlderThan(1000000)
+	if err != nil {
+		t.Fatalf("Error deleting events by age: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("There should be 0 events deleted, not %d", count)
+	}
+
+	count, err = db.DeletePushedEventsOlderThan(0)
+	if err != nil {
+		t.Fatalf("Error deleting pushed events by age: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("There should be 0 events deleted, not %d", count)
+	}
+
+	count, err = db.DeleteOldestEvents(10, true)
+	if err != nil {
+		t.Fatalf("Error deleting oldest events: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("There should be 0 events deleted, not %d", count)
+	}
+
+	count, err = db.DeleteOldestEvents(10, false)
+	if err != nil {
+		t.Fatalf("Error deleting oldest events: %v", err)
+	}
+	if count != 10 {
+		t.Fatalf("There should be 10 events deleted, not %d", count)
+	}
+
+	ids, err := db.AddEvents([]contract.Event{{Device: "batch"}, {Device: "batch"}})
+	if err != nil {
+		t.Fatalf("Error adding events: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("There should be 2 ids, not %d", len(ids))
+	}
+	count, err = db.EventCountByDeviceId("batch")
+	if err != nil {
+		t.Fatalf("Error getting events count: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("There should be 2 events, not %d", count)
+	}
+	count, err = db.DeleteEventsByDevice("batch")
+	if err != nil {
+		t.Fatalf("Error deleting events by device: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("There should be 2 events deleted, not %d", count)
+	}
+
+	size, err := db.DatabaseSize()
+	if err != nil {
+		t.Fatalf("Error getting database size: %v", err)
+	}
+	if size <= 0 {
+		t.Fatalf("Database size should be positive, not %d", size)
+	}
+
+	count, err = db.DeleteEventsOlderThan(0)
+	if err != nil {
+		t.Fatalf("Error deleting events by age: %v", err)
+	}
+	if count != 89 {
+		t.Fatalf("There should be 89 events deleted, not %d", count)
+	}
+
 	err = db.ScrubAllEvents()
 	if err != nil {
 		t.Fatalf("Error removing all events")
@@ -244,8 +407,112 @@ func testDBEvents(t *testing.T, db interfaces.DBClient) {
 	}
 }
 
+func testDBReadings(t *testing.T, db interfaces.DBClient) {
+	err := db.ScrubAllEvents()
+	if err != nil {
+		t.Fatalf("Error removing all events")
+	}
+
+	// 10 events with 3 readings each, 5 events per device
+	for i := 0; i < 10; i++ {
+		e := contract.Event{Device: fmt.Sprintf("device%d", i%2)}
+		for j := 0; j < 3; j++ {
+			e.Readings = append(e.Readings, contract.Reading{Name: fmt.Sprintf("name%d", j), Value: strconv.Itoa(i)})
+		}
+		_, err = db.AddEvent(e)
+		if err != nil {
+			t.Fatalf("Error adding event: %v", err)
+		}
+	}
+
+	count, err := db.ReadingCount()
+	if err != nil {
+		t.Fatalf("Error getting readings count: %v", err)
+	}
+	if count != 30 {
+		t.Fatalf("There should be 30 readings, not %d", count)
+	}
+
+	readings, err := db.Readings(0, 100, dbp.OldestFirst)
+	if err != nil {
+		t.Fatalf("Error getting readings: %v", err)
+	}
+	if len(readings) != 30 {
+		t.Fatalf("There should be 30 readings, not %d", len(readings))
+	}
+
+	r, err := db.ReadingById(readings[0].Id)
+	if err != nil {
+		t.Fatalf("Error getting reading by id: %v", err)
+	}
+	if r.Id != readings[0].Id || r.Device == "" {
+		t.Fatalf("Unexpected reading %v", r)
+	}
+
+	_, err = db.ReadingById("INVALID")
+	if err == nil {
+		t.Fatalf("Reading should not be found")
+	}
+
+	readings, err = db.ReadingsByDevice("device0", 0, 100, dbp.OldestFirst)
+	if err != nil {
+		t.Fatalf("Error getting readings by device: %v", err)
+	}
+	if len(readings) != 15 {
+		t.Fatalf("There should be 15 readings, not %d", len(readings))
+	}
+
+	readings, err = db.ReadingsByName("name1", 2, 5, dbp.OldestFirst)
+	if err != nil {
+		t.Fatalf("Error getting readings by name: %v", err)
+	}
+	if len(readings) != 5 {
+		t.Fatalf("There should be 5 readings, not %d", len(readings))
+	}
+	for _, r := range readings {
+		if r.Name != "name1" {
+			t.Fatalf("Reading %s should not be returned", r.Name)
+		}
+	}
+
+	readings, err = db.ReadingsByDeviceAndName("device1", "name2", 0, dbp.MakeTimestamp()+1, 0, 100, dbp.NewestFirst)
+	if err != nil {
+		t.Fatalf("Error getting readings by device and name: %v", err)
+	}
+	if len(readings) != 5 {
+		t.Fatalf("There should be 5 readings, not %d", len(readings))
+	}
+	for i := 1; i < len(readings); i++ {
+		if readings[i].Created > readings[i-1].Created {
+			t.Fatalf("Readings should be sorted newest first")
+		}
+	}
+
+	readings, err = db.ReadingsByCreationTime(0, dbp.MakeTimestamp()+1, 25, 100, dbp.OldestFirst)
+	if err != nil {
+		t.Fatalf("Error getting readings by creation time: %v", err)
+	}
+	if len(readings) != 5 {
+		t.Fatalf("There should be 5 readings, not %d", len(readings))
+	}
+
+	err = db.ScrubAllEvents()
+	if err != nil {
+		t.Fatalf("Error removing all events")
+	}
+
+	count, err = db.ReadingCount()
+	if err != nil {
+		t.Fatalf("Error getting readings count: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("There should be 0 readings, not %d", count)
+	}
+}
+
 func TestDataDB(t *testing.T, db interfaces.DBClient) {
 	testDBEvents(t, db)
+	testDBReadings(t, db)
 
 	db.CloseSession()
 	// Calling CloseSession twice to test that there is no panic when closing an
@@ -34,6 +34,7 @@ const (
 	ApiNotifyRegistrationRoute = "/api/v1/notify/registrations"
 	ApiPingRoute               = "/api/v1/ping"
 	ApiProvisionWatcherRoute   = "/api/v1/provisionwatcher"
+	ApiReadingRoute            = "/api/v1/reading"
 	ApiRegistrationRoute       = "/api/v1/registration"
 	ApiRegistrationByNameRoute = ApiRegistrationRoute + "/name"
 	ApiSubscriptionRoute       = "/api/v1/subscription"
@@ -56,6 +57,7 @@ const (
 )
 
 const (
+	Accept          = "Accept"
 	ContentType     = "Content-Type"
 	ContentTypeCBOR = "application/cbor"
 	ContentTypeJSON = "application/json"
@@ -19,16 +19,19 @@ package coredata
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/url"
 	"strconv"
 
 	"github.com/Circutor/edgex/pkg/clients"
 	"github.com/Circutor/edgex/pkg/models"
+	"github.com/ugorji/go/codec"
 )
 
 type EventClient interface {
 	Events(ctx context.Context) ([]models.Event, error)
 	EventsUnpushed(ctx context.Context, nUnpushed int) ([]models.Event, error)
+	EventsUnpushedForRegistration(registration string, nUnpushed int, ctx context.Context) ([]models.Event, error)
 	Event(id string, ctx context.Context) (models.Event, error)
 	EventCount(ctx context.Context) (int, error)
 	EventCountForDevice(deviceId string, ctx context.Context) (int, error)
@@ -36,10 +39,12 @@ type EventClient interface {
 	EventsForInterval(start int, end int, limit int, ctx context.Context) ([]models.Event, error)
 	EventsForDeviceAndValueDescriptor(deviceId string, vd string, limit int, ctx context.Context) ([]models.Event, error)
 	Add(event *models.Event, ctx context.Context) (string, error)
+	AddBatch(events []models.Event, ctx context.Context) ([]models.EventBatchStatus, error)
 	DeleteForDevice(id string, ctx context.Context) error
 	DeleteOld(age int, ctx context.Context) error
 	Delete(id string, ctx context.Context) error
 	MarkPushed(id string, ctx context.Context) error
+	MarkPushedForRegistration(id string, registration string, ctx context.Context) error
 }
 
 type EventRestClient struct {
@@ -85,6 +90,11 @@ func (e *EventRestClient) EventsUnpushed(ctx context.Context, nUnpushed int) ([]
 	return e.requestEventSlice(e.url+"/unpushed/"+strconv.Itoa(nUnpushed), ctx)
 }
 
+// Get a list of the events not pushed yet by the registration
+func (e *EventRestClient) EventsUnpushedForRegistration(registration string, nUnpushed int, ctx context.Context) ([]models.Event, error) {
+	return e.requestEventSlice(e.url+"/unpushed/"+strconv.Itoa(nUnpushed)+"?registration="+url.QueryEscape(registration), ctx)
+}
+
 // Get the event by id
 func (e *EventRestClient) Event(id string, ctx context.Context) (models.Event, error) {
 	return e.requestEvent(e.url+"/"+id, ctx)
@@ -119,12 +129,42 @@ func (e *EventRestClient) EventsForDeviceAndValueDescriptor(deviceId string, vd
 func (e *EventRestClient) Add(event *models.Event, ctx context.Context) (string, error) {
 	content := clients.FromContext(clients.ContentType, ctx)
 	if content == clients.ContentTypeCBOR {
-		return clients.PostRequest(e.url, event.CBOR(), ctx)
+		data := event.CBOR()
+		if len(data) == 0 {
+			return "", errors.New("error encoding the event as CBOR")
+		}
+		return clients.PostRequest(e.url, data, ctx)
 	} else {
 		return clients.PostJsonRequest(e.url, event, ctx)
 	}
 }
 
+// Add a batch of events
+// Return the status of each event in the same order
+func (e *EventRestClient) AddBatch(events []models.Event, ctx context.Context) ([]models.EventBatchStatus, error) {
+	var data string
+	var err error
+
+	content := clients.FromContext(clients.ContentType, ctx)
+	if content == clients.ContentTypeCBOR {
+		var encoded []byte
+		err = codec.NewEncoderBytes(&encoded, &codec.CborHandle{}).Encode(events)
+		if err != nil {
+			return nil, err
+		}
+		data, err = clients.PostRequest(e.url+"/batch", encoded, ctx)
+	} else {
+		data, err = clients.PostJsonRequest(e.url+"/batch", events, ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.EventBatchStatus, 0)
+	err = json.Unmarshal([]byte(data), &statuses)
+	return statuses, err
+}
+
 // Delete event by id
 func (e *EventRestClient) Delete(id string, ctx context.Context) error {
 	return clients.DeleteRequest(e.url+"/id/"+id, ctx)
@@ -145,3 +185,9 @@ func (e *EventRestClient) MarkPushed(id string, ctx context.Context) error {
 	_, err := clients.PutRequest(e.url+"/id/"+id, nil, ctx)
 	return err
 }
+
+// Mark event as pushed by the registration
+func (e *EventRestClient) MarkPushedForRegistration(id string, registration string, ctx context.Context) error {
+	_, err := clients.PutRequest(e.url+"/id/"+id+"?registration="+url.QueryEscape(registration), nil, ctx)
+	return err
+}
@@ -23,6 +23,8 @@ import (
 	"testing"
 
 	"github.com/Circutor/edgex/pkg/clients"
+	"github.com/Circutor/edgex/pkg/models"
+	"github.com/ugorji/go/codec"
 )
 
 const (
@@ -58,6 +60,67 @@ func TestMarkPushed(t *testing.T) {
 	}
 }
 
+func TestMarkPushedForRegistration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method != http.MethodPut {
+			t.Errorf("expected http method is PUT, active http method is : %s", r.Method)
+		}
+
+		url := clients.ApiEventRoute + "/id/" + TestId
+		if r.URL.EscapedPath() != url {
+			t.Errorf("expected uri path is %s, actual uri path is %s", url, r.URL.EscapedPath())
+		}
+
+		if registration := r.URL.Query().Get("registration"); registration != "dexma" {
+			t.Errorf("expected registration is dexma, actual registration is %s", registration)
+		}
+	}))
+
+	defer ts.Close()
+
+	url := ts.URL + clients.ApiEventRoute
+	ec := NewEventClient(url)
+
+	err := ec.MarkPushedForRegistration(TestId, "dexma", context.Background())
+
+	if err != nil {
+		t.FailNow()
+	}
+}
+
+func TestGetEventsUnpushedForRegistration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		url := clients.ApiEventRoute + "/unpushed/10"
+		if r.URL.EscapedPath() != url {
+			t.Errorf("expected uri path is %s, actual uri path is %s", url, r.URL.EscapedPath())
+		}
+
+		if registration := r.URL.Query().Get("registration"); registration != "dexma" {
+			t.Errorf("expected registration is dexma, actual registration is %s", registration)
+		}
+
+		w.Write([]byte("[{\"Device\" : \"" + TestEventDevice1 + "\"}]"))
+	}))
+
+	defer ts.Close()
+
+	url := ts.URL + clients.ApiEventRoute
+	ec := NewEventClient(url)
+
+	eArr, err := ec.EventsUnpushedForRegistration("dexma", 10, context.Background())
+	if err != nil {
+		t.FailNow()
+	}
+
+	if len(eArr) != 1 {
+		t.Errorf("expected event array's length is 1, actual array's length is : %d", len(eArr))
+	}
+}
+
 func TestGetEvents(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -105,3 +168,75 @@ func TestGetEvents(t *testing.T) {
 		t.Errorf("expected second events's device is : %s, actual device is : %s ", TestEventDevice2, e2.Device)
 	}
 }
+
+func TestAddEventCBOR(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(clients.ContentType) != clients.ContentTypeCBOR {
+			t.Errorf("expected content type is %s, actual content type is %s", clients.ContentTypeCBOR, r.Header.Get(clients.ContentType))
+		}
+
+		var e models.Event
+		err := codec.NewDecoder(r.Body, &codec.CborHandle{}).Decode(&e)
+		if err != nil {
+			t.Errorf("error decoding the event: %v", err)
+		}
+		if e.Device != TestEventDevice1 {
+			t.Errorf("expected event's device is : %s, actual device is : %s", TestEventDevice1, e.Device)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(TestId))
+	}))
+
+	defer ts.Close()
+
+	url := ts.URL + clients.ApiEventRoute
+	ec := NewEventClient(url)
+
+	ctx := context.WithValue(context.Background(), clients.ContentType, clients.ContentTypeCBOR)
+	id, err := ec.Add(&models.Event{Device: TestEventDevice1}, ctx)
+	if err != nil {
+		t.FailNow()
+	}
+
+	if id != TestId {
+		t.Errorf("expected id is %s, actual id is %s", TestId, id)
+	}
+}
+
+func TestAddEventBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected http method is POST, active http method is : %s", r.Method)
+		}
+
+		url := clients.ApiEventRoute + "/batch"
+		if r.URL.EscapedPath() != url {
+			t.Errorf("expected uri path is %s, actual uri path is %s", url, r.URL.EscapedPath())
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("[" +
+			"{\"id\" : \"" + TestId + "\", \"status\" : 200}," +
+			"{\"status\" : 404, \"error\" : \"device not found\"}" +
+			"]"))
+	}))
+
+	defer ts.Close()
+
+	url := ts.URL + clients.ApiEventRoute
+	ec := NewEventClient(url)
+
+	statuses, err := ec.AddBatch([]models.Event{{Device: TestEventDevice1}, {Device: TestEventDevice2}}, context.Background())
+	if err != nil {
+		t.FailNow()
+	}
+
+	if len(statuses) != 2 {
+		t.Fatalf("expected status array's length is 2, actual array's length is : %d", len(statuses))
+	}
+
+	if statuses[0].ID != TestId || statuses[1].Status != http.StatusNotFound {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+}
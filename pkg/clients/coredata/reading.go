@@ -0,0 +1,102 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package coredata
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"github.com/Circutor/edgex/pkg/clients"
+	"github.com/Circutor/edgex/pkg/models"
+)
+
+type ReadingClient interface {
+	Readings(ctx context.Context) ([]models.Reading, error)
+	ReadingCount(ctx context.Context) (int, error)
+	Reading(id string, ctx context.Context) (models.Reading, error)
+	ReadingsForDevice(deviceId string, offset int, limit int, ctx context.Context) ([]models.Reading, error)
+	ReadingsForName(name string, offset int, limit int, ctx context.Context) ([]models.Reading, error)
+	ReadingsForDeviceAndName(deviceId string, name string, offset int, limit int, ctx context.Context) ([]models.Reading, error)
+	ReadingsForDeviceAndNameInterval(deviceId string, name string, start int, end int, offset int, limit int, ctx context.Context) ([]models.Reading, error)
+	ReadingsForInterval(start int, end int, offset int, limit int, ctx context.Context) ([]models.Reading, error)
+}
+
+type ReadingRestClient struct {
+	url string
+}
+
+func NewReadingClient(url string) ReadingClient {
+	r := ReadingRestClient{url: url}
+	return &r
+}
+
+// Helper method to request and decode a reading slice
+func (r *ReadingRestClient) requestReadingSlice(url string, offset int, ctx context.Context) ([]models.Reading, error) {
+	if offset > 0 {
+		url += "?offset=" + strconv.Itoa(offset)
+	}
+
+	data, err := clients.GetRequest(url, ctx)
+	if err != nil {
+		return []models.Reading{}, err
+	}
+
+	rSlice := make([]models.Reading, 0)
+	err = json.Unmarshal(data, &rSlice)
+	return rSlice, err
+}
+
+// Get a list of all readings
+func (r *ReadingRestClient) Readings(ctx context.Context) ([]models.Reading, error) {
+	return r.requestReadingSlice(r.url, 0, ctx)
+}
+
+// Get reading count
+func (r *ReadingRestClient) ReadingCount(ctx context.Context) (int, error) {
+	return clients.CountRequest(r.url+"/count", ctx)
+}
+
+// Get the reading by id
+func (r *ReadingRestClient) Reading(id string, ctx context.Context) (models.Reading, error) {
+	data, err := clients.GetRequest(r.url+"/"+id, ctx)
+	if err != nil {
+		return models.Reading{}, err
+	}
+
+	reading := models.Reading{}
+	err = json.Unmarshal(data, &reading)
+	return reading, err
+}
+
+// Get readings for device
+func (r *ReadingRestClient) ReadingsForDevice(deviceId string, offset int, limit int, ctx context.Context) ([]models.Reading, error) {
+	return r.requestReadingSlice(r.url+"/device/"+url.QueryEscape(deviceId)+"/"+strconv.Itoa(limit), offset, ctx)
+}
+
+// Get readings by name
+func (r *ReadingRestClient) ReadingsForName(name string, offset int, limit int, ctx context.Context) ([]models.Reading, error) {
+	return r.requestReadingSlice(r.url+"/name/"+url.QueryEscape(name)+"/"+strconv.Itoa(limit), offset, ctx)
+}
+
+// Get readings for device and name
+func (r *ReadingRestClient) ReadingsForDeviceAndName(deviceId string, name string, offset int, limit int, ctx context.Context) ([]models.Reading, error) {
+	return r.requestReadingSlice(r.url+"/name/"+url.QueryEscape(name)+"/device/"+url.QueryEscape(deviceId)+"/"+strconv.Itoa(limit), offset, ctx)
+}
+
+// Get readings for device and name created in the interval
+func (r *ReadingRestClient) ReadingsForDeviceAndNameInterval(deviceId string, name string, start int, end int, offset int, limit int, ctx context.Context) ([]models.Reading, error) {
+	return r.requestReadingSlice(r.url+"/name/"+url.QueryEscape(name)+"/device/"+url.QueryEscape(deviceId)+"/"+
+		strconv.Itoa(start)+"/"+strconv.Itoa(end)+"/"+strconv.Itoa(limit), offset, ctx)
+}
+
+// Get readings for interval
+func (r *ReadingRestClient) ReadingsForInterval(start int, end int, offset int, limit int, ctx context.Context) ([]models.Reading, error) {
+	return r.requestReadingSlice(r.url+"/"+strconv.Itoa(start)+"/"+strconv.Itoa(end)+"/"+strconv.Itoa(limit), offset, ctx)
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package coredata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Circutor/edgex/pkg/clients"
+)
+
+const (
+	TestReadingName = "temperature"
+)
+
+func TestGetReadingsForDeviceAndName(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		if r.Method != http.MethodGet {
+			t.Errorf("expected http method is GET, active http method is : %s", r.Method)
+		}
+
+		url := clients.ApiReadingRoute + "/name/" + TestReadingName + "/device/" + TestEventDevice1 + "/10"
+		if r.URL.EscapedPath() != url {
+			t.Errorf("expected uri path is %s, actual uri path is %s", url, r.URL.EscapedPath())
+		}
+
+		if r.URL.RawQuery != "offset=5" {
+			t.Errorf("expected query is offset=5, actual query is %s", r.URL.RawQuery)
+		}
+
+		w.Write([]byte("[" +
+			"{" +
+			"\"device\" : \"" + TestEventDevice1 + "\"," +
+			"\"name\" : \"" + TestReadingName + "\"" +
+			"}" +
+			"]"))
+	}))
+
+	defer ts.Close()
+
+	url := ts.URL + clients.ApiReadingRoute
+	rc := NewReadingClient(url)
+
+	rArr, err := rc.ReadingsForDeviceAndName(TestEventDevice1, TestReadingName, 5, 10, context.Background())
+	if err != nil {
+		t.FailNow()
+	}
+
+	if len(rArr) != 1 {
+		t.Errorf("expected reading array's length is 1, actual array's length is : %d", len(rArr))
+	}
+
+	if rArr[0].Device != TestEventDevice1 || rArr[0].Name != TestReadingName {
+		t.Errorf("unexpected reading %v", rArr[0])
+	}
+}
+
+func TestGetReadingsForInterval(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+
+		url := clients.ApiReadingRoute + "/1000/2000/10"
+		if r.URL.EscapedPath() != url {
+			t.Errorf("expected uri path is %s, actual uri path is %s", url, r.URL.EscapedPath())
+		}
+
+		if r.URL.RawQuery != "" {
+			t.Errorf("expected no query, actual query is %s", r.URL.RawQuery)
+		}
+
+		w.Write([]byte("[]"))
+	}))
+
+	defer ts.Close()
+
+	url := ts.URL + clients.ApiReadingRoute
+	rc := NewReadingClient(url)
+
+	rArr, err := rc.ReadingsForInterval(1000, 2000, 0, 10, context.Background())
+	if err != nil {
+		t.FailNow()
+	}
+
+	if len(rArr) != 0 {
+		t.Errorf("expected reading array's length is 0, actual array's length is : %d", len(rArr))
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/Circutor/edgex/pkg/clients"
@@ -98,7 +99,7 @@ func TestGetAddressable(t *testing.T) {
 		t.Error(err.Error())
 	}
 
-	if receivedAddressable != addressable {
+	if !reflect.DeepEqual(receivedAddressable, addressable) {
 		t.Errorf("expected addressable: %s, actual addressable: %s", receivedAddressable, addressable)
 	}
 }
@@ -136,7 +137,7 @@ func TestGetAddressableForName(t *testing.T) {
 		t.Error(err.Error())
 	}
 
-	if receivedAddressable != addressable {
+	if !reflect.DeepEqual(receivedAddressable, addressable) {
 		t.Errorf("expected addressable: %s, actual addressable: %s", receivedAddressable, addressable)
 	}
 }
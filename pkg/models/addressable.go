@@ -40,8 +40,40 @@ type Addressable struct {
 	Password    string `json:"password"`    // Password of the user for authentication for the addressable
 	Topic       string `json:"topic"`       // Topic for message bus addressables
 	Certificate string `json:"certificate"` // Certificate used for authentication
+	Key         string `json:"key"`         // Private key of the certificate
+	// Options of the HTTP and TLS connections
+	CACertificate      string            `json:"caCertificate"`      // CA bundle to verify the server certificate, the system one if empty
+	InsecureSkipVerify bool              `json:"insecureSkipVerify"` // Do not verify the server certificate
+	ServerName         string            `json:"serverName"`         // Name expected in the server certificate, the address if empty
+	AuthType           string            `json:"authType"`           // Authentication scheme: BASIC, BEARER or APIKEY
+	AuthHeader         string            `json:"authHeader"`         // Header holding the API key
+	Token              string            `json:"token"`              // Bearer token or API key
+	Headers            map[string]string `json:"headers"`            // Additional headers of the requests
+	Timeout            int               `json:"timeout"`            // Milliseconds to wait for each request
+	MQTT               MQTTOptions       `json:"mqtt"`               // Options of the MQTT destinations
 }
 
+// Options of the connections and publications to MQTT brokers
+type MQTTOptions struct {
+	QoS               byte   `json:"qos"`               // QoS of the publications
+	Retain            bool   `json:"retain"`            // Publish retained messages
+	PersistentSession bool   `json:"persistentSession"` // Keep the session in the broker across reconnections (no clean session)
+	KeepAlive         int    `json:"keepAlive"`         // Seconds between keepalives, the client default if 0
+	WillTopic         string `json:"willTopic"`         // Topic of the last will, none if empty
+	WillMessage       string `json:"willMessage"`
+	WillQoS           byte   `json:"willQos"`
+	WillRetain        bool   `json:"willRetain"`
+	MaxInFlight       int    `json:"maxInFlight"`   // Publications not confirmed yet by the broker, synchronous publishing if 0
+	SplitReadings     bool   `json:"splitReadings"` // Publish each reading as an event, implied by the topics with reading placeholders
+}
+
+// Authentication schemes of the addressables
+const (
+	AuthBasic  = "BASIC"
+	AuthBearer = "BEARER"
+	AuthAPIKey = "APIKEY"
+)
+
 // Custom marshaling for JSON
 // Treat the strings as pointers so they can be null in JSON
 func (a Addressable) MarshalJSON() ([]byte, error) {
@@ -59,9 +91,23 @@ func (a Addressable) MarshalJSON() ([]byte, error) {
 		Password    *string `json:"password,omitempty"`    // Password of the user for authentication for the addressable
 		Topic       *string `json:"topic,omitempty"`       // Topic for message bus addressables
 		Certificate *string `json:"certificate,omitempty"` // Certificate used for authentication
+		Key         *string `json:"key,omitempty"`         // Private key of the certificate
+
+		CACertificate      *string           `json:"caCertificate,omitempty"`
+		InsecureSkipVerify bool              `json:"insecureSkipVerify,omitempty"`
+		ServerName         *string           `json:"serverName,omitempty"`
+		AuthType           *string           `json:"authType,omitempty"`
+		AuthHeader         *string           `json:"authHeader,omitempty"`
+		Token              *string           `json:"token,omitempty"`
+		Headers            map[string]string `json:"headers,omitempty"`
+		Timeout            int               `json:"timeout,omitempty"`
+		MQTT               *MQTTOptions      `json:"mqtt,omitempty"`
 	}{
-		BaseObject: a.BaseObject,
-		Port:       a.Port,
+		BaseObject:         a.BaseObject,
+		Port:               a.Port,
+		InsecureSkipVerify: a.InsecureSkipVerify,
+		Headers:            a.Headers,
+		Timeout:            a.Timeout,
 	}
 
 	if a.Id != "" {
@@ -99,6 +145,27 @@ func (a Addressable) MarshalJSON() ([]byte, error) {
 	if a.Certificate != "" {
 		aux.Certificate = &a.Certificate
 	}
+	if a.Key != "" {
+		aux.Key = &a.Key
+	}
+	if a.CACertificate != "" {
+		aux.CACertificate = &a.CACertificate
+	}
+	if a.ServerName != "" {
+		aux.ServerName = &a.ServerName
+	}
+	if a.AuthType != "" {
+		aux.AuthType = &a.AuthType
+	}
+	if a.AuthHeader != "" {
+		aux.AuthHeader = &a.AuthHeader
+	}
+	if a.Token != "" {
+		aux.Token = &a.Token
+	}
+	if a.MQTT != (MQTTOptions{}) {
+		aux.MQTT = &a.MQTT
+	}
 
 	return json.Marshal(aux)
 }
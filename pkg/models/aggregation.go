@@ -0,0 +1,43 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Values of the aggregated readings
+const (
+	AggregateLast = "LAST"
+	AggregateAvg  = "AVG"
+)
+
+// Aggregation - Window in which the readings of each device are aggregated into a single event
+type Aggregation struct {
+	Window string `json:"window,omitempty"` // Duration such as 15m, the readings are not aggregated if empty
+	Value  string `json:"value,omitempty"`  // Value of the aggregated readings, LAST (default) or AVG
+}
+
+func (a Aggregation) isEmpty() bool {
+	return a.Window == "" && a.Value == ""
+}
+
+// Validate checks the window and the value
+func (a Aggregation) Validate() error {
+	if a.Window != "" {
+		window, err := time.ParseDuration(a.Window)
+		if err != nil || window <= 0 {
+			return fmt.Errorf("Aggregation window invalid: %s", a.Window)
+		}
+	}
+	if a.Value != "" && a.Value != AggregateLast && a.Value != AggregateAvg {
+		return fmt.Errorf("Aggregation value invalid: %s", a.Value)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import "testing"
+
+func TestAggregationValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		aggregation Aggregation
+		expectError bool
+	}{
+		{"empty", Aggregation{}, false},
+		{"valid", Aggregation{Window: "15m", Value: AggregateAvg}, false},
+		{"invalid window", Aggregation{Window: "15"}, true},
+		{"negative window", Aggregation{Window: "-1m"}, true},
+		{"invalid value", Aggregation{Window: "1h", Value: "MEDIAN"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.aggregation.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Aggregation should be invalid")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Aggregation should be valid: %v", err)
+			}
+		})
+	}
+}
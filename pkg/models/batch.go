@@ -0,0 +1,42 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Batch - Limits of the batches of events sent in a single payload, the batch is sent when any of them is reached
+type Batch struct {
+	MaxEvents  int    `json:"maxEvents,omitempty"`
+	MaxBytes   int    `json:"maxBytes,omitempty"`   // Size of the formatted events
+	MaxLatency string `json:"maxLatency,omitempty"` // Duration such as 30s the first event of a batch waits at most
+}
+
+// IsEnabled returns true if the events are sent in batches
+func (b Batch) IsEnabled() bool {
+	return b.MaxEvents > 1 || b.MaxBytes > 0 || b.MaxLatency != ""
+}
+
+// Validate checks the limits
+func (b Batch) Validate() error {
+	if b.MaxEvents < 0 {
+		return fmt.Errorf("Batch max events invalid: %d", b.MaxEvents)
+	}
+	if b.MaxBytes < 0 {
+		return fmt.Errorf("Batch max bytes invalid: %d", b.MaxBytes)
+	}
+	if b.MaxLatency != "" {
+		latency, err := time.ParseDuration(b.MaxLatency)
+		if err != nil || latency <= 0 {
+			return fmt.Errorf("Batch max latency invalid: %s", b.MaxLatency)
+		}
+	}
+	return nil
+}
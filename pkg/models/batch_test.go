@@ -0,0 +1,41 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import "testing"
+
+func TestBatchValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		batch       Batch
+		enabled     bool
+		expectError bool
+	}{
+		{"empty", Batch{}, false, false},
+		{"single event", Batch{MaxEvents: 1}, false, false},
+		{"valid", Batch{MaxEvents: 100, MaxBytes: 65536, MaxLatency: "30s"}, true, false},
+		{"latency", Batch{MaxLatency: "1m"}, true, false},
+		{"negative events", Batch{MaxEvents: -1}, false, true},
+		{"negative bytes", Batch{MaxBytes: -1}, false, true},
+		{"invalid latency", Batch{MaxLatency: "soon"}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.batch.IsEnabled() != tt.enabled {
+				t.Errorf("Batch enabled should be %v", tt.enabled)
+			}
+			err := tt.batch.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Batch should be invalid")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Batch should be valid: %v", err)
+			}
+		})
+	}
+}
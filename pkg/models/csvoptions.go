@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// CSV columns
+const (
+	CSVDevice = "device"
+	CSVName   = "name"
+	CSVValue  = "value"
+	CSVOrigin = "origin"
+	CSVMin    = "min"
+	CSVAvg    = "avg"
+	CSVMax    = "max"
+)
+
+// Formats of the CSV origin column, any other value is used as a Go time layout
+const (
+	TimestampMillis  = "MILLIS"
+	TimestampSeconds = "SECONDS"
+	TimestampRFC3339 = "RFC3339"
+)
+
+// CSVOptions - Layout of the CSV export data, a row per reading
+type CSVOptions struct {
+	Delimiter       string   `json:"delimiter,omitempty"`       // Single character, comma by default
+	Header          bool     `json:"header,omitempty"`          // Write the names of the columns first
+	Columns         []string `json:"columns,omitempty"`         // device, name, value and origin by default
+	TimestampFormat string   `json:"timestampFormat,omitempty"` // Milliseconds by default
+}
+
+func (o CSVOptions) isEmpty() bool {
+	return o.Delimiter == "" && !o.Header && len(o.Columns) == 0 && o.TimestampFormat == ""
+}
+
+// Validate checks the delimiter and the columns
+func (o CSVOptions) Validate() error {
+	if o.Delimiter != "" {
+		r, size := utf8.DecodeRuneInString(o.Delimiter)
+		if size != len(o.Delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+			return fmt.Errorf("CSV delimiter invalid: %s", o.Delimiter)
+		}
+	}
+	for _, column := range o.Columns {
+		switch column {
+		case CSVDevice, CSVName, CSVValue, CSVOrigin, CSVMin, CSVAvg, CSVMax:
+		default:
+			return fmt.Errorf("CSV column invalid: %s", column)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import "testing"
+
+func TestCSVOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     CSVOptions
+		expectError bool
+	}{
+		{"empty", CSVOptions{}, false},
+		{"valid", CSVOptions{Delimiter: ";", Columns: []string{CSVDevice, CSVOrigin, CSVAvg}}, false},
+		{"tab delimiter", CSVOptions{Delimiter: "\t"}, false},
+		{"long delimiter", CSVOptions{Delimiter: ";;"}, true},
+		{"quote delimiter", CSVOptions{Delimiter: "\""}, true},
+		{"unknown column", CSVOptions{Columns: []string{"unit"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.options.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Options should be invalid")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Options should be valid: %v", err)
+			}
+		})
+	}
+}
@@ -16,8 +16,9 @@ package models
 
 // Encryption types
 const (
-	EncNone = "NONE"
-	EncAes  = "AES"
+	EncNone   = "NONE"
+	EncAes    = "AES"     // AES-CBC with the static initializing vector, kept for the existing clients
+	EncAesGcm = "AES_GCM" // AES-GCM with a random nonce and a key derived with HKDF-SHA256 for every message
 )
 
 // EncryptionDetails - Provides details for encryption
@@ -27,13 +27,14 @@ import (
  * Event struct to hold event data
  */
 type Event struct {
-	ID       string    `json:"id" codec:"omitempty"`
-	Pushed   int64     `json:"pushed" codec:"omitempty"`
-	Device   string    `json:"device" codec:"omitempty"` // Device identifier (name or id)
-	Created  int64     `json:"created" codec:"omitempty"`
-	Modified int64     `json:"modified" codec:"omitempty"`
-	Origin   int64     `json:"origin" codec:"omitempty"`
-	Readings []Reading `json:"readings" codec:"omitempty"` // List of readings
+	ID       string           `json:"id" codec:"id,omitempty"`
+	Pushed   int64            `json:"pushed" codec:"pushed,omitempty"`
+	Device   string           `json:"device" codec:"device,omitempty"` // Device identifier (name or id)
+	Created  int64            `json:"created" codec:"created,omitempty"`
+	Modified int64            `json:"modified" codec:"modified,omitempty"`
+	Origin   int64            `json:"origin" codec:"origin,omitempty"`
+	Readings []Reading        `json:"readings" codec:"readings,omitempty"`         // List of readings
+	PushedTo map[string]int64 `json:"pushedTo" codec:"pushedTo,omitempty" xml:"-"` // When the event was pushed by each export registration
 }
 
 func encodeAsCBOR(e Event) ([]byte, error) {
@@ -52,18 +53,20 @@ func encodeAsCBOR(e Event) ([]byte, error) {
 // Custom marshaling to make empty strings null
 func (e Event) MarshalJSON() ([]byte, error) {
 	test := struct {
-		ID       *string   `json:"id,omitempty"`
-		Pushed   int64     `json:"pushed,omitempty"`
-		Device   *string   `json:"device,omitempty"` // Device identifier (name or id)
-		Created  int64     `json:"created,omitempty"`
-		Modified int64     `json:"modified,omitempty"`
-		Origin   int64     `json:"origin,omitempty"`
-		Readings []Reading `json:"readings,omitempty"` // List of readings
+		ID       *string          `json:"id,omitempty"`
+		Pushed   int64            `json:"pushed,omitempty"`
+		Device   *string          `json:"device,omitempty"` // Device identifier (name or id)
+		Created  int64            `json:"created,omitempty"`
+		Modified int64            `json:"modified,omitempty"`
+		Origin   int64            `json:"origin,omitempty"`
+		Readings []Reading        `json:"readings,omitempty"` // List of readings
+		PushedTo map[string]int64 `json:"pushedTo,omitempty"`
 	}{
 		Pushed:   e.Pushed,
 		Created:  e.Created,
 		Modified: e.Modified,
 		Origin:   e.Origin,
+		PushedTo: e.PushedTo,
 	}
 
 	// Empty strings are null
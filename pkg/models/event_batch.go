@@ -0,0 +1,15 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+// Result of adding one of the events of a batch
+type EventBatchStatus struct {
+	ID     string `json:"id,omitempty"`    // ID of the added event
+	Status int    `json:"status"`          // HTTP status code of the event
+	Error  string `json:"error,omitempty"` // Why the event was not added
+}
@@ -18,6 +18,8 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+
+	"github.com/ugorji/go/codec"
 )
 
 var TestEvent = Event{Pushed: 123, Created: 123, Origin: 123, Modified: 123, Readings: []Reading{TestReading}}
@@ -72,3 +74,29 @@ func TestEvent_String(t *testing.T) {
 		})
 	}
 }
+
+func TestEvent_CBOR(t *testing.T) {
+	event := TestEvent
+	event.ID = "id1"
+	event.Device = TestDeviceName
+	event.PushedTo = map[string]int64{"registration1": 123}
+
+	var handle codec.CborHandle
+	var keys map[string]interface{}
+	if err := codec.NewDecoderBytes(event.CBOR(), &handle).Decode(&keys); err != nil {
+		t.Fatalf("Error decoding CBOR: %v", err)
+	}
+	for _, key := range []string{"id", "pushed", "device", "created", "modified", "origin", "readings", "pushedTo"} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("CBOR key %s missing in %v", key, keys)
+		}
+	}
+
+	var decoded Event
+	if err := codec.NewDecoderBytes(event.CBOR(), &handle).Decode(&decoded); err != nil {
+		t.Fatalf("Error decoding CBOR: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, event) {
+		t.Errorf("Event.CBOR() round trip = %v, want %v", decoded, event)
+	}
+}
@@ -14,8 +14,72 @@
 
 package models
 
+import (
+	"fmt"
+	"regexp"
+)
+
+// Operators of the value conditions
+const (
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+)
+
 // Filter - Specifies the client filters on reading data
 type Filter struct {
-	DeviceIDs          []string `json:"deviceIdentifiers,omitempty"`
-	ValueDescriptorIDs []string `json:"valueDescriptorIdentifiers,omitempty"`
+	DeviceIDs          []string         `json:"deviceIdentifiers,omitempty"`
+	ValueDescriptorIDs []string         `json:"valueDescriptorIdentifiers,omitempty"`
+	DevicePattern      string           `json:"devicePattern,omitempty"`  // Regular expression on the device names
+	ReadingPattern     string           `json:"readingPattern,omitempty"` // Regular expression on the reading names
+	DeviceLabels       []string         `json:"deviceLabels,omitempty"`   // Labels, or key=value labels, the device has in metadata
+	Values             []ValueCondition `json:"values,omitempty"`
+	Deadbands          []Deadband       `json:"deadbands,omitempty"`
+}
+
+// ValueCondition - Numeric comparison the readings must satisfy
+type ValueCondition struct {
+	Reading  string  `json:"reading,omitempty"` // Name of the readings compared, all of them if empty
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+}
+
+// Deadband - Report by exception, the readings are only exported when they change enough since the last exported one
+type Deadband struct {
+	Reading  string  `json:"reading,omitempty"`  // Name of the readings, all of them if empty
+	Absolute float64 `json:"absolute,omitempty"` // Minimum change of the value
+	Percent  float64 `json:"percent,omitempty"`  // Minimum change as a percentage of the last exported value
+}
+
+// IsEmpty returns true if the filter accepts every event
+func (f Filter) IsEmpty() bool {
+	return len(f.DeviceIDs) == 0 && len(f.ValueDescriptorIDs) == 0 &&
+		f.DevicePattern == "" && f.ReadingPattern == "" && len(f.DeviceLabels) == 0 &&
+		len(f.Values) == 0 && len(f.Deadbands) == 0
+}
+
+// Validate checks the patterns, operators and deadbands
+func (f Filter) Validate() error {
+	if _, err := regexp.Compile(f.DevicePattern); err != nil {
+		return fmt.Errorf("Device pattern invalid: %v", err)
+	}
+	if _, err := regexp.Compile(f.ReadingPattern); err != nil {
+		return fmt.Errorf("Reading pattern invalid: %v", err)
+	}
+	for _, c := range f.Values {
+		switch c.Operator {
+		case OpGreater, OpGreaterEqual, OpLess, OpLessEqual, OpEqual, OpNotEqual:
+		default:
+			return fmt.Errorf("Value operator invalid: %s", c.Operator)
+		}
+	}
+	for _, d := range f.Deadbands {
+		if d.Absolute < 0 || d.Percent < 0 || (d.Absolute == 0 && d.Percent == 0) {
+			return fmt.Errorf("Deadband of %s invalid, it needs a positive absolute or percent change", d.Reading)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,37 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import "testing"
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      Filter
+		expectError bool
+	}{
+		{"empty", Filter{}, false},
+		{"valid", Filter{DevicePattern: "^meter", Values: []ValueCondition{{Operator: OpGreater, Value: 230}}, Deadbands: []Deadband{{Percent: 5}}}, false},
+		{"invalid device pattern", Filter{DevicePattern: "("}, true},
+		{"invalid reading pattern", Filter{ReadingPattern: "[a"}, true},
+		{"invalid operator", Filter{Values: []ValueCondition{{Operator: "=>"}}}, true},
+		{"empty deadband", Filter{Deadbands: []Deadband{{Reading: "POWER"}}}, true},
+		{"negative deadband", Filter{Deadbands: []Deadband{{Absolute: -1}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Filter should be invalid")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Filter should be valid: %v", err)
+			}
+		})
+	}
+}
@@ -48,6 +48,7 @@ const (
 	FormatCSV             = "CSV"
 	FormatThingsBoardJSON = "THINGSBOARD_JSON"
 	FormatNOOP            = "NOOP"
+	FormatTemplate        = "TEMPLATE"
 )
 
 const (
@@ -71,6 +72,12 @@ type Registration struct {
 	Compression string            `json:"compression"`
 	Enable      bool              `json:"enable"`
 	Destination string            `json:"destination"`
+	CSV         CSVOptions        `json:"csv"`
+	Template    string            `json:"template"` // Go text/template rendering the events with the TEMPLATE format
+	Aggregation Aggregation       `json:"aggregation"`
+	Batch       Batch             `json:"batch"`
+	// Dexma parameter codes by reading name, added to the builtin ones
+	DexmaParameters map[string]int `json:"dexmaParameters"`
 }
 
 // Custom marshaling for JSON
@@ -89,6 +96,12 @@ func (reg Registration) MarshalJSON() ([]byte, error) {
 		Compression *string            `json:"compression,omitempty"`
 		Enable      bool               `json:"enable"`
 		Destination *string            `json:"destination,omitempty"`
+		CSV         *CSVOptions        `json:"csv,omitempty"`
+		Template    *string            `json:"template,omitempty"`
+		Aggregation *Aggregation       `json:"aggregation,omitempty"`
+		Batch       *Batch             `json:"batch,omitempty"`
+		// Dexma parameter codes by reading name
+		DexmaParameters map[string]int `json:"dexmaParameters,omitempty"`
 	}{
 		Created:     reg.Created,
 		Modified:    reg.Modified,
@@ -107,7 +120,7 @@ func (reg Registration) MarshalJSON() ([]byte, error) {
 	if reg.Format != "" {
 		aux.Format = &reg.Format
 	}
-	if len(reg.Filter.DeviceIDs) != 0 || len(reg.Filter.ValueDescriptorIDs) != 0 {
+	if !reg.Filter.IsEmpty() {
 		aux.Filter = &reg.Filter
 	}
 	if reg.Encryption.Algo != "" || reg.Encryption.Key != "" || reg.Encryption.InitVector != "" {
@@ -119,6 +132,21 @@ func (reg Registration) MarshalJSON() ([]byte, error) {
 	if reg.Destination != "" {
 		aux.Destination = &reg.Destination
 	}
+	if !reg.CSV.isEmpty() {
+		aux.CSV = &reg.CSV
+	}
+	if reg.Template != "" {
+		aux.Template = &reg.Template
+	}
+	if !reg.Aggregation.isEmpty() {
+		aux.Aggregation = &reg.Aggregation
+	}
+	if reg.Batch != (Batch{}) {
+		aux.Batch = &reg.Batch
+	}
+	if len(reg.DexmaParameters) != 0 {
+		aux.DexmaParameters = reg.DexmaParameters
+	}
 
 	return json.Marshal(aux)
 }
@@ -147,10 +175,21 @@ func (reg Registration) Validate() (bool, error) {
 		reg.Format != FormatAWSJSON &&
 		reg.Format != FormatCSV &&
 		reg.Format != FormatThingsBoardJSON &&
-		reg.Format != FormatNOOP {
+		reg.Format != FormatNOOP &&
+		reg.Format != FormatTemplate {
 		return false, fmt.Errorf("Format invalid: %s", reg.Format)
 	}
 
+	if reg.Format == FormatTemplate && reg.Template == "" {
+		return false, fmt.Errorf("Template is required by format %s", FormatTemplate)
+	}
+
+	if reg.Format == FormatTemplate {
+		if err := ValidateTemplate(reg.Template); err != nil {
+			return false, err
+		}
+	}
+
 	if reg.Destination != DestMQTT &&
 		reg.Destination != DestZMQ &&
 		reg.Destination != DestIotCoreMQTT &&
@@ -165,9 +204,45 @@ func (reg Registration) Validate() (bool, error) {
 	}
 
 	if reg.Encryption.Algo != EncNone &&
-		reg.Encryption.Algo != EncAes {
+		reg.Encryption.Algo != EncAes &&
+		reg.Encryption.Algo != EncAesGcm {
 		return false, fmt.Errorf("Encryption invalid: %s", reg.Encryption.Algo)
 	}
 
+	if reg.Encryption.Algo == EncAesGcm && reg.Encryption.Key == "" {
+		return false, fmt.Errorf("Encryption key is required by %s", EncAesGcm)
+	}
+
+	if reg.Addressable.AuthType != "" &&
+		reg.Addressable.AuthType != AuthBasic &&
+		reg.Addressable.AuthType != AuthBearer &&
+		reg.Addressable.AuthType != AuthAPIKey {
+		return false, fmt.Errorf("Authentication invalid: %s", reg.Addressable.AuthType)
+	}
+
+	if reg.Addressable.MQTT.QoS > 2 {
+		return false, fmt.Errorf("MQTT QoS invalid: %d", reg.Addressable.MQTT.QoS)
+	}
+
+	if reg.Addressable.MQTT.WillQoS > 2 {
+		return false, fmt.Errorf("MQTT will QoS invalid: %d", reg.Addressable.MQTT.WillQoS)
+	}
+
+	if err := reg.Filter.Validate(); err != nil {
+		return false, err
+	}
+
+	if err := reg.CSV.Validate(); err != nil {
+		return false, err
+	}
+
+	if err := reg.Aggregation.Validate(); err != nil {
+		return false, err
+	}
+
+	if err := reg.Batch.Validate(); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
@@ -0,0 +1,29 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// TemplateFuncs - Functions the templates of the TEMPLATE format can use besides the builtin ones
+// Export-distro implements them, they are only declared here to parse the templates
+var TemplateFuncs = []string{"timestamp", "now", "number", "integer", "json", "label"}
+
+// ValidateTemplate checks the syntax of a template and that the functions it calls exist
+func ValidateTemplate(text string) error {
+	funcs := template.FuncMap{}
+	for _, name := range TemplateFuncs {
+		funcs[name] = func(args ...interface{}) interface{} { return nil }
+	}
+	if _, err := template.New("registration").Funcs(funcs).Parse(text); err != nil {
+		return fmt.Errorf("Template invalid: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+//
+// Copyright (c) 2020
+// Circutor
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package models
+
+import "testing"
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		expectError bool
+	}{
+		{"fields", `{"device":"{{.Device}}"}`, false},
+		{"functions", `{{range .Readings}}{{json .Name}}:{{number .Value}} {{timestamp .Origin "RFC3339"}}{{end}}`, false},
+		{"unclosed action", `{{.Device`, true},
+		{"unknown function", `{{float .Value}}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTemplate(tt.template)
+			if tt.expectError && err == nil {
+				t.Errorf("Template should be invalid")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Template should be valid: %v", err)
+			}
+		})
+	}
+}